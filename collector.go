@@ -0,0 +1,253 @@
+package discordgo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ReactionCollector describes which reactions Session.CollectReactions
+// collects and when it stops.
+type ReactionCollector struct {
+	// The channel and message whose reactions are collected.
+	ChannelID string
+	MessageID string
+
+	// Filter, if set, is called with every reaction added to the message,
+	// and only the reactions for which it returns true are collected.
+	Filter func(r *MessageReactionAdd) bool
+
+	// Stop, if set, is called with all of the collected reactions after each
+	// collected reaction, and collection ends when it returns true.
+	Stop func(collected []*MessageReactionAdd) bool
+
+	// Timeout is how long reactions are collected for.
+	// If zero, reactions are collected until Stop returns true.
+	Timeout time.Duration
+}
+
+// CollectReactions blocks while collecting the reactions added to a message
+// and returns them once the collector's Timeout elapses or its Stop function
+// returns true. The temporary handler it adds is removed before returning.
+//
+// eg:
+//
+//	reactions := Session.CollectReactions(discordgo.ReactionCollector{
+//	    ChannelID: m.ChannelID,
+//	    MessageID: m.ID,
+//	    Filter: func(r *discordgo.MessageReactionAdd) bool {
+//	        return r.UserID != s.State.User.ID
+//	    },
+//	    Timeout: 30 * time.Second,
+//	})
+func (s *Session) CollectReactions(c ReactionCollector) (collected []*MessageReactionAdd) {
+	s.collectEvents(c.Timeout, func(send func(interface{})) func() {
+		return s.AddHandler(func(_ *Session, r *MessageReactionAdd) {
+			if r.MessageReaction == nil || r.ChannelID != c.ChannelID || r.MessageID != c.MessageID {
+				return
+			}
+			if c.Filter == nil || c.Filter(r) {
+				send(r)
+			}
+		})
+	}, func(e interface{}) bool {
+		collected = append(collected, e.(*MessageReactionAdd))
+		return c.Stop != nil && c.Stop(collected)
+	})
+	return
+}
+
+// MessageCollector describes which messages Session.CollectMessages
+// collects and when it stops.
+type MessageCollector struct {
+	// The channel in which messages are collected.
+	ChannelID string
+
+	// UserID, if set, only collects messages sent by this user.
+	UserID string
+
+	// Filter, if set, is called with every message sent in the channel by
+	// the user, and only the messages for which it returns true are collected.
+	Filter func(m *MessageCreate) bool
+
+	// Stop, if set, is called with all of the collected messages after each
+	// collected message, and collection ends when it returns true.
+	Stop func(collected []*MessageCreate) bool
+
+	// Timeout is how long messages are collected for.
+	// If zero, messages are collected until Stop returns true.
+	Timeout time.Duration
+}
+
+// CollectMessages blocks while collecting the messages sent in a channel and
+// returns them once the collector's Timeout elapses or its Stop function
+// returns true. The temporary handler it adds is removed before returning.
+func (s *Session) CollectMessages(c MessageCollector) (collected []*MessageCreate) {
+	s.collectEvents(c.Timeout, func(send func(interface{})) func() {
+		return s.AddHandler(func(_ *Session, m *MessageCreate) {
+			if m.Message == nil || m.ChannelID != c.ChannelID {
+				return
+			}
+			if c.UserID != "" && (m.Author == nil || m.Author.ID != c.UserID) {
+				return
+			}
+			if c.Filter == nil || c.Filter(m) {
+				send(m)
+			}
+		})
+	}, func(e interface{}) bool {
+		collected = append(collected, e.(*MessageCreate))
+		return c.Stop != nil && c.Stop(collected)
+	})
+	return
+}
+
+// WaitForFirstMessage blocks until a message matching the collector is sent and
+// returns it, or returns nil once the collector's Timeout elapses.
+// The collector's Stop function is ignored.
+//
+// eg:
+//
+//	s.ChannelMessageSend(m.ChannelID, "What is your name?")
+//	answer := s.WaitForFirstMessage(discordgo.MessageCollector{
+//	    ChannelID: m.ChannelID,
+//	    UserID:    m.Author.ID,
+//	    Timeout:   time.Minute,
+//	})
+func (s *Session) WaitForFirstMessage(c MessageCollector) *MessageCreate {
+	c.Stop = func(collected []*MessageCreate) bool {
+		return true
+	}
+	if collected := s.CollectMessages(c); len(collected) > 0 {
+		return collected[0]
+	}
+	return nil
+}
+
+// collectEvents passes the events sent by the handler added with add to
+// collect until timeout elapses or collect returns true, and then removes the
+// handler. A zero timeout never elapses.
+func (s *Session) collectEvents(timeout time.Duration, add func(send func(interface{})) func(), collect func(interface{}) bool) {
+	ch := make(chan interface{})
+	done := make(chan struct{})
+
+	remove := add(func(e interface{}) {
+		select {
+		case ch <- e:
+		case <-done:
+		}
+	})
+	// done is closed first, so that a handler blocked on ch returns before
+	// the handler is removed.
+	defer remove()
+	defer close(done)
+
+	var elapsed <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		elapsed = timer.C
+	}
+
+	for {
+		select {
+		case e := <-ch:
+			if collect(e) {
+				return
+			}
+		case <-elapsed:
+			return
+		}
+	}
+}
+
+// ErrNonceTimeout is returned by WaitForMessage when no message with the nonce
+// is received before the timeout.
+var ErrNonceTimeout = errors.New("timed out waiting for a message with the nonce")
+
+// maxTrackedNonces is how many echoed messages with a nonce are remembered
+// when Session.TrackSentNonces is set.
+const maxTrackedNonces = 100
+
+// WaitForMessage blocks until the gateway echoes the message sent with the
+// given nonce and returns it, which confirms the message was delivered.
+// With TrackSentNonces set, messages which arrived shortly before the call
+// are returned as well, otherwise WaitForMessage must be called before the
+// message is sent.
+// nonce   : The nonce of the message, see MessageSend.Nonce.
+// timeout : How long to wait for the message, zero waits forever.
+func (s *Session) WaitForMessage(nonce string, timeout time.Duration) (*Message, error) {
+	ch := make(chan *Message, 1)
+	remove := s.AddHandler(func(_ *Session, m *MessageCreate) {
+		if m.Message == nil || m.Nonce != nonce {
+			return
+		}
+		select {
+		case ch <- m.Message:
+		default:
+		}
+	})
+	defer remove()
+
+	// Checked after adding the handler, so that no message is missed.
+	if m := s.trackedNonce(nonce); m != nil {
+		return m, nil
+	}
+
+	var elapsed <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		elapsed = timer.C
+	}
+
+	select {
+	case m := <-ch:
+		return m, nil
+	case <-elapsed:
+		return nil, ErrNonceTimeout
+	}
+}
+
+// trackNonce remembers a message echoed with a nonce, forgetting the oldest
+// one once maxTrackedNonces messages are remembered.
+func (s *Session) trackNonce(m *Message) {
+	if m == nil || m.Nonce == "" {
+		return
+	}
+
+	s.noncesMu.Lock()
+	defer s.noncesMu.Unlock()
+
+	if s.nonceMessages == nil {
+		s.nonceMessages = make(map[string]*Message)
+	}
+	if _, ok := s.nonceMessages[m.Nonce]; !ok {
+		s.nonceOrder = append(s.nonceOrder, m.Nonce)
+	}
+	s.nonceMessages[m.Nonce] = m
+
+	if len(s.nonceOrder) > maxTrackedNonces {
+		delete(s.nonceMessages, s.nonceOrder[0])
+		s.nonceOrder = s.nonceOrder[1:]
+	}
+}
+
+// trackedNonce returns the remembered message with the nonce, if any.
+func (s *Session) trackedNonce(nonce string) *Message {
+	s.noncesMu.Lock()
+	defer s.noncesMu.Unlock()
+
+	return s.nonceMessages[nonce]
+}
+
+// newNonce returns a random message nonce.
+func newNonce() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,163 @@
+package discordgo
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// waitForHandlers waits until at least n handlers are added for eventType.
+// It may be called from any goroutine.
+func waitForHandlers(t *testing.T, s *Session, eventType string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.handlersMu.RLock()
+		added := len(s.handlers[eventType])
+		s.handlersMu.RUnlock()
+		if added >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Errorf("handler for %s was not added", eventType)
+}
+
+func TestCollectReactions(t *testing.T) {
+	d, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SyncEvents = true
+
+	go func() {
+		waitForHandlers(t, d, messageReactionAddEventType, 1)
+		for _, r := range []*MessageReaction{
+			{UserID: "u1", ChannelID: "c", MessageID: "m", Emoji: Emoji{Name: "👍"}},
+			{UserID: "u2", ChannelID: "c", MessageID: "other", Emoji: Emoji{Name: "👍"}},
+			{UserID: "bot", ChannelID: "c", MessageID: "m", Emoji: Emoji{Name: "👍"}},
+			{UserID: "u3", ChannelID: "c", MessageID: "m", Emoji: Emoji{Name: "👎"}},
+		} {
+			d.handleEvent(messageReactionAddEventType, &MessageReactionAdd{MessageReaction: r})
+		}
+	}()
+
+	collected := d.CollectReactions(ReactionCollector{
+		ChannelID: "c",
+		MessageID: "m",
+		Filter: func(r *MessageReactionAdd) bool {
+			return r.UserID != "bot"
+		},
+		Stop: func(collected []*MessageReactionAdd) bool {
+			return len(collected) == 2
+		},
+		Timeout: 5 * time.Second,
+	})
+
+	if len(collected) != 2 || collected[0].UserID != "u1" || collected[1].UserID != "u3" {
+		t.Errorf("collected %d reactions, want u1 and u3", len(collected))
+	}
+
+	d.handlersMu.RLock()
+	left := len(d.handlers[messageReactionAddEventType])
+	d.handlersMu.RUnlock()
+	if left != 0 {
+		t.Errorf("%d handlers left after collecting", left)
+	}
+}
+
+func TestCollectReactionsTimeout(t *testing.T) {
+	d, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	collected := d.CollectReactions(ReactionCollector{ChannelID: "c", MessageID: "m", Timeout: 50 * time.Millisecond})
+	if len(collected) != 0 {
+		t.Errorf("collected %d reactions, want 0", len(collected))
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("returned after %v, before the timeout", elapsed)
+	}
+}
+
+func TestCollectMessages(t *testing.T) {
+	d, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SyncEvents = true
+
+	go func() {
+		waitForHandlers(t, d, messageCreateEventType, 1)
+		for _, m := range []*Message{
+			{ID: "1", ChannelID: "other", Author: &User{ID: "u"}, Content: "yes"},
+			{ID: "2", ChannelID: "c", Author: &User{ID: "someone"}, Content: "yes"},
+			{ID: "3", ChannelID: "c", Author: &User{ID: "u"}, Content: "maybe"},
+			{ID: "4", ChannelID: "c", Author: &User{ID: "u"}, Content: "yes"},
+		} {
+			d.handleEvent(messageCreateEventType, &MessageCreate{Message: m})
+		}
+	}()
+
+	m := d.WaitForFirstMessage(MessageCollector{
+		ChannelID: "c",
+		UserID:    "u",
+		Filter: func(m *MessageCreate) bool {
+			return m.Content == "yes"
+		},
+		Timeout: 5 * time.Second,
+	})
+	if m == nil || m.ID != "4" {
+		t.Errorf("WaitForFirstMessage() = %+v, want message 4", m)
+	}
+
+	d.handlersMu.RLock()
+	left := len(d.handlers[messageCreateEventType])
+	d.handlersMu.RUnlock()
+	if left != 0 {
+		t.Errorf("%d handlers left after collecting", left)
+	}
+
+	if m := d.WaitForFirstMessage(MessageCollector{ChannelID: "c", Timeout: 10 * time.Millisecond}); m != nil {
+		t.Errorf("WaitForFirstMessage() = %+v after timeout, want nil", m)
+	}
+}
+
+func TestWaitForMessage(t *testing.T) {
+	d, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SyncEvents = true
+	d.TrackSentNonces = true
+
+	// Echoed before waiting.
+	d.handleEvent(messageCreateEventType, &MessageCreate{Message: &Message{ID: "1", Nonce: "early"}})
+	if m, err := d.WaitForMessage("early", time.Second); err != nil || m.ID != "1" {
+		t.Errorf("WaitForMessage(early) = %+v, %v", m, err)
+	}
+
+	// Echoed while waiting.
+	go func() {
+		waitForHandlers(t, d, messageCreateEventType, 1)
+		d.handleEvent(messageCreateEventType, &MessageCreate{Message: &Message{ID: "2", Nonce: "other"}})
+		d.handleEvent(messageCreateEventType, &MessageCreate{Message: &Message{ID: "3", Nonce: "late"}})
+	}()
+	if m, err := d.WaitForMessage("late", 5*time.Second); err != nil || m.ID != "3" {
+		t.Errorf("WaitForMessage(late) = %+v, %v", m, err)
+	}
+
+	if _, err := d.WaitForMessage("missing", 10*time.Millisecond); err != ErrNonceTimeout {
+		t.Errorf("WaitForMessage(missing) err = %v, want ErrNonceTimeout", err)
+	}
+
+	for i := 0; i < maxTrackedNonces+10; i++ {
+		d.trackNonce(&Message{Nonce: strconv.Itoa(i)})
+	}
+	if len(d.nonceMessages) != maxTrackedNonces || d.trackedNonce("0") != nil {
+		t.Errorf("tracked %d nonces, want the %d most recent", len(d.nonceMessages), maxTrackedNonces)
+	}
+}
@@ -25,10 +25,12 @@ import (
 const VERSION = "0.28.1"
 
 // New creates a new Discord session with provided token.
-// If the token is for a bot, it must be prefixed with "Bot "
+// If the token is for a bot, it should be prefixed with "Bot "
 // 		e.g. "Bot ..."
 // Or if it is an OAuth2 token, it must be prefixed with "Bearer "
 //		e.g. "Bearer ..."
+// Tokens with neither prefix are sent as bot tokens, unless
+// Session.ShouldPrefixToken is set to false.
 func New(token string) (s *Session, err error) {
 
 	// Create an empty Session interface.
@@ -40,6 +42,7 @@ func New(token string) (s *Session, err error) {
 		ShouldReconnectOnError:             true,
 		ShouldReconnectVoiceOnSessionError: true,
 		ShouldRetryOnRateLimit:             true,
+		ShouldPrefixToken:                  true,
 		ShardID:                            0,
 		ShardCount:                         1,
 		MaxRestRetries:                     3,
@@ -1,12 +1,18 @@
 package discordgo
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"runtime"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 //////////////////////////////////////////////////////////////////////////////
@@ -185,6 +191,195 @@ func TestRemoveHandler(t *testing.T) {
 	}
 }
 
+type testHandlers struct {
+	messages int
+	guilds   int
+}
+
+func (h *testHandlers) OnMessageCreate(s *Session, m *MessageCreate) { h.messages++ }
+func (h *testHandlers) OnGuildCreate(s *Session, g *GuildCreate)     { h.guilds++ }
+func (h *testHandlers) Helper(s *Session)                            {}
+func (h *testHandlers) String() string                               { return "" }
+
+func TestAddHandlers(t *testing.T) {
+	h := &testHandlers{}
+	d := Session{SyncEvents: true}
+	r := d.AddHandlers(h)
+
+	d.handleEvent(messageCreateEventType, &MessageCreate{})
+	d.handleEvent(guildCreateEventType, &GuildCreate{Guild: &Guild{}})
+
+	r()
+
+	d.handleEvent(messageCreateEventType, &MessageCreate{})
+	d.handleEvent(guildCreateEventType, &GuildCreate{Guild: &Guild{}})
+
+	if h.messages != 1 || h.guilds != 1 {
+		t.Fatalf("handlers called %d and %d times, want once each", h.messages, h.guilds)
+	}
+}
+
+func TestAddMiddleware(t *testing.T) {
+
+	testHandlerCalled := int32(0)
+	testHandler := func(s *Session, m *MessageCreate) {
+		atomic.AddInt32(&testHandlerCalled, 1)
+	}
+
+	var order []int
+	d := Session{SyncEvents: true}
+	d.AddHandler(testHandler)
+	d.AddMiddleware(func(s *Session, e interface{}) bool {
+		order = append(order, 1)
+		return true
+	})
+	r := d.AddMiddleware(func(s *Session, e interface{}) bool {
+		order = append(order, 2)
+		return false
+	})
+
+	d.handleEvent(messageCreateEventType, &MessageCreate{})
+
+	if atomic.LoadInt32(&testHandlerCalled) != 0 {
+		t.Fatalf("testHandler was called although a middleware halted the event.")
+	}
+	if len(order) != 2 || order[0] != 1 || order[1] != 2 {
+		t.Fatalf("middlewares were called in order %v, want [1 2]", order)
+	}
+
+	r()
+
+	d.handleEvent(messageCreateEventType, &MessageCreate{})
+
+	if atomic.LoadInt32(&testHandlerCalled) != 1 {
+		t.Fatalf("testHandler was not called once after removing the middleware.")
+	}
+}
+
+func TestCloseGracefully(t *testing.T) {
+
+	testHandlerDone := int32(0)
+	testHandler := func(s *Session, d *Disconnect) {
+		time.Sleep(100 * time.Millisecond)
+		atomic.StoreInt32(&testHandlerDone, 1)
+	}
+
+	d := Session{}
+	d.AddHandler(testHandler)
+
+	if err := d.CloseGracefully(context.Background()); err != nil {
+		t.Fatalf("CloseGracefully returned error: %+v", err)
+	}
+
+	// CloseGracefully must not return before the Disconnect handler has.
+	if atomic.LoadInt32(&testHandlerDone) != 1 {
+		t.Fatalf("testHandler had not returned.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := d.CloseGracefully(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("CloseGracefully returned %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestCloseGracefullyStopsNewHandlers(t *testing.T) {
+	d := Session{}
+	release := make(chan struct{})
+	d.AddHandler(func(s *Session, d *Disconnect) {
+		<-release
+	})
+	rateLimits := int32(0)
+	d.AddHandler(func(s *Session, r *RateLimit) {
+		atomic.AddInt32(&rateLimits, 1)
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- d.CloseGracefully(context.Background())
+	}()
+
+	for closing := false; !closing; time.Sleep(time.Millisecond) {
+		d.handlersMu.RLock()
+		closing = d.handlersClosing > 0
+		d.handlersMu.RUnlock()
+	}
+
+	// Events emitted while CloseGracefully waits are not handled.
+	d.handleEvent(rateLimitEventType, &RateLimit{TooManyRequests: &TooManyRequests{}})
+	if n := atomic.LoadInt32(&rateLimits); n != 0 {
+		t.Errorf("%d RateLimit handlers were started while closing", n)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("CloseGracefully returned error: %+v", err)
+	}
+
+	d.handleEvent(rateLimitEventType, &RateLimit{TooManyRequests: &TooManyRequests{}})
+	d.handlersWg.Wait()
+	if n := atomic.LoadInt32(&rateLimits); n != 1 {
+		t.Errorf("%d RateLimit handlers were started after closing, want 1", n)
+	}
+}
+
+func TestDuplicateDispatchDropped(t *testing.T) {
+	d, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SyncEvents = true
+
+	var seen []string
+	d.AddHandler(func(s *Session, m *MessageCreate) {
+		seen = append(seen, m.ID)
+	})
+
+	dispatch := func(seq int, id string) {
+		msg := fmt.Sprintf(`{"op": 0, "s": %d, "t": "MESSAGE_CREATE", "d": {"id": %q}}`, seq, id)
+		if _, err := d.onEvent(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("onEvent returned error: %+v", err)
+		}
+	}
+
+	dispatch(1, "a")
+	dispatch(2, "b")
+	// Replayed after a resume.
+	dispatch(2, "b")
+	dispatch(1, "a")
+	dispatch(3, "c")
+
+	if got := strings.Join(seen, ","); got != "a,b,c" {
+		t.Errorf("dispatched %q, want %q", got, "a,b,c")
+	}
+}
+
+func TestConnectionStatus(t *testing.T) {
+	d, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SyncEvents = true
+
+	if status := d.ConnectionStatus(); status != ConnectionStatusDisconnected {
+		t.Fatalf("initial status = %v, want %v", status, ConnectionStatusDisconnected)
+	}
+
+	d.setConnectionStatus(ConnectionStatusIdentifying)
+	msg := `{"op": 0, "s": 1, "t": "READY", "d": {"session_id": "session", "user": {"id": "bot"}}}`
+	if _, err := d.onEvent(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatalf("onEvent returned error: %+v", err)
+	}
+	if status := d.ConnectionStatus(); status != ConnectionStatusReady {
+		t.Errorf("status after READY = %v, want %v", status, ConnectionStatusReady)
+	}
+
+	d.Close()
+	if status := d.ConnectionStatus(); status != ConnectionStatusDisconnected {
+		t.Errorf("status after Close = %v, want %v", status, ConnectionStatusDisconnected)
+	}
+}
+
 func TestScheduledEvents(t *testing.T) {
 	if dgBot == nil {
 		t.Skip("Skipping, dgBot not set.")
@@ -300,3 +495,199 @@ func TestComplexScheduledEvents(t *testing.T) {
 		t.Fatal("err on GuildScheduledEventEdit. Change of entity type to voice failed")
 	}
 }
+
+type testCustomEvent struct {
+	Name string `json:"name"`
+}
+
+func TestRegisterEventType(t *testing.T) {
+	d, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SyncEvents = true
+
+	d.RegisterEventType("TEST_CUSTOM_EVENT", testCustomEvent{})
+
+	var got []string
+	d.AddHandler(func(s *Session, e *testCustomEvent) {
+		got = append(got, e.Name)
+	})
+	var raw int
+	d.AddHandler(func(s *Session, e interface{}) {
+		if _, ok := e.(*testCustomEvent); ok {
+			raw++
+		}
+	})
+
+	msg := `{"op": 0, "s": 1, "t": "TEST_CUSTOM_EVENT", "d": {"name": "hello"}}`
+	if _, err := d.onEvent(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatalf("onEvent returned error: %+v", err)
+	}
+
+	if len(got) != 1 || got[0] != "hello" {
+		t.Errorf("custom handler got %v, want [hello]", got)
+	}
+	if raw != 1 {
+		t.Errorf("interface{} handler called %d times, want 1", raw)
+	}
+
+	// Known events cannot be overridden.
+	d.LogLevel = -1
+	d.RegisterEventType("MESSAGE_CREATE", testCustomEvent{})
+	if _, ok := d.customEventTypes["MESSAGE_CREATE"]; ok {
+		t.Error("registered a known event type")
+	}
+}
+
+func TestGatewayWriteHook(t *testing.T) {
+	received := make(chan []byte, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- msg
+		}
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	d, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.wsConn = conn
+
+	var ops []int
+	d.GatewayWriteHook = func(op int, data interface{}) {
+		ops = append(ops, op)
+	}
+
+	if err := d.UpdateGameStatus(0, "testing"); err != nil {
+		t.Fatalf("UpdateGameStatus returned error: %+v", err)
+	}
+	if err := d.GatewayWriteStruct(struct {
+		Op   int         `json:"op"`
+		Data interface{} `json:"d"`
+	}{8, nil}); err != nil {
+		t.Fatalf("GatewayWriteStruct returned error: %+v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatal("payload was not sent")
+		}
+	}
+	if fmt.Sprint(ops) != "[3 8]" {
+		t.Errorf("hook called with ops %v, want [3 8]", ops)
+	}
+}
+
+func TestReconnectResumed(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies []string
+		resumed bool
+	}{
+		{"replayed events", []string{
+			`{"op": 0, "s": 6, "t": "MESSAGE_CREATE", "d": {"id": "missed"}}`,
+			`{"op": 0, "s": 7, "t": "RESUMED", "d": {}}`,
+		}, true},
+		{"invalid session", []string{`{"op": 9, "d": false}`}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				conn.WriteMessage(websocket.TextMessage, []byte(`{"op": 10, "d": {"heartbeat_interval": 60000}}`))
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+				for _, reply := range tt.replies {
+					conn.WriteMessage(websocket.TextMessage, []byte(reply))
+				}
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						return
+					}
+				}
+			}))
+			defer server.Close()
+
+			d, err := New("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			d.SyncEvents = true
+			d.gateway = "ws" + strings.TrimPrefix(server.URL, "http")
+			d.sessionID = "session"
+			atomic.StoreInt64(d.sequence, 5)
+			d.setConnectionStatus(ConnectionStatusReconnecting)
+
+			var event *Reconnect
+			d.AddHandler(func(s *Session, r *Reconnect) {
+				event = r
+			})
+
+			if err := d.Open(); err != nil {
+				t.Fatalf("Open returned error: %+v", err)
+			}
+			defer d.Close()
+
+			if event == nil || event.Resumed != tt.resumed {
+				t.Errorf("Reconnect event = %+v, want Resumed %v", event, tt.resumed)
+			}
+		})
+	}
+}
+
+func TestReconnectStatusWhileWaiting(t *testing.T) {
+	d, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.ShouldReconnectOnError = true
+	d.gateway = "ws://127.0.0.1:1"
+
+	done := make(chan struct{})
+	go func() {
+		d.reconnect()
+		close(done)
+	}()
+
+	// The failed attempt clears the gateway, then reconnect waits a second.
+	for failed := false; !failed; time.Sleep(time.Millisecond) {
+		d.RLock()
+		failed = d.gateway == ""
+		d.RUnlock()
+	}
+	time.Sleep(100 * time.Millisecond)
+	if status := d.ConnectionStatus(); status != ConnectionStatusReconnecting {
+		t.Errorf("status while waiting to reconnect = %v, want %v", status, ConnectionStatusReconnecting)
+	}
+
+	// Stops the reconnect loop at its next attempt.
+	d.Lock()
+	d.wsConn = &websocket.Conn{}
+	d.Unlock()
+	<-done
+}
@@ -44,6 +44,10 @@ var (
 	EndpointCDNBanners      = EndpointCDN + "banners/"
 	EndpointCDNGuilds       = EndpointCDN + "guilds/"
 	EndpointCDNRoleIcons    = EndpointCDN + "role-icons/"
+	EndpointCDNStickers     = EndpointCDN + "stickers/"
+
+	EndpointMedia         = "https://media.discordapp.net/"
+	EndpointMediaStickers = EndpointMedia + "stickers/"
 
 	EndpointVoice        = EndpointAPI + "/voice/"
 	EndpointVoiceRegions = EndpointVoice + "regions"
@@ -138,12 +142,15 @@ var (
 	EndpointChannelMessagePin                   = func(cID, mID string) string { return EndpointChannel(cID) + "/pins/" + mID }
 	EndpointChannelMessageCrosspost             = func(cID, mID string) string { return EndpointChannel(cID) + "/messages/" + mID + "/crosspost" }
 	EndpointChannelFollow                       = func(cID string) string { return EndpointChannel(cID) + "/followers" }
+	EndpointChannelVoiceStatus                  = func(cID string) string { return EndpointChannel(cID) + "/voice-status" }
 	EndpointThreadMembers                       = func(tID string) string { return EndpointChannel(tID) + "/thread-members" }
 	EndpointThreadMember                        = func(tID, mID string) string { return EndpointThreadMembers(tID) + "/" + mID }
 
 	EndpointGroupIcon = func(cID, hash string) string { return EndpointCDNChannelIcons + cID + "/" + hash + ".png" }
 
 	EndpointSticker            = func(sID string) string { return EndpointStickers + sID }
+	EndpointStickerImage       = func(sID, ext string) string { return EndpointCDNStickers + sID + "." + ext }
+	EndpointStickerImageGIF    = func(sID string) string { return EndpointMediaStickers + sID + ".gif" }
 	EndpointNitroStickersPacks = EndpointAPI + "/sticker-packs"
 
 	EndpointChannelWebhooks = func(cID string) string { return EndpointChannel(cID) + "/webhooks" }
@@ -233,6 +240,10 @@ var (
 
 	EndpointInvite = func(iID string) string { return EndpointAPI + "invites/" + iID }
 
+	EndpointMessageLink = func(gID, cID, mID string) string {
+		return EndpointDiscord + "channels/" + gID + "/" + cID + "/" + mID
+	}
+
 	EndpointEmoji         = func(eID string) string { return EndpointCDN + "emojis/" + eID + ".png" }
 	EndpointEmojiAnimated = func(eID string) string { return EndpointCDN + "emojis/" + eID + ".gif" }
 
@@ -1,5 +1,7 @@
 package discordgo
 
+import "reflect"
+
 // EventHandler is an interface for Discord events.
 type EventHandler interface {
 	// Type returns the type of event this handler belongs to.
@@ -112,13 +114,13 @@ func (s *Session) addEventHandlerOnce(eventHandler EventHandler) func() {
 // List of events can be found at this page, with corresponding names in the
 // library for each event: https://discord.com/developers/docs/topics/gateway#event-names
 // There are also synthetic events fired by the library internally which are
-// available for handling, like Connect, Disconnect, and RateLimit.
+// available for handling, like Connect, Disconnect, Reconnect, and RateLimit.
 // events.go contains all of the Discord WSAPI and synthetic events that can be handled.
 //
 // The return value of this method is a function, that when called will remove the
 // event handler.
 func (s *Session) AddHandler(handler interface{}) func() {
-	eh := handlerForInterface(handler)
+	eh := s.handlerForInterface(handler)
 
 	if eh == nil {
 		s.log(LogError, "Invalid handler type, handler will never be called")
@@ -128,11 +130,41 @@ func (s *Session) AddHandler(handler interface{}) func() {
 	return s.addEventHandler(eh)
 }
 
+// AddHandlers adds every exported method of obj which is a valid event
+// handler, as accepted by AddHandler. Other methods are ignored.
+//
+// eg:
+//     type Bot struct{}
+//
+//     func (b *Bot) OnMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {}
+//     func (b *Bot) OnGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {}
+//
+//     Session.AddHandlers(&Bot{})
+//
+// The return value of this method is a function, that when called will remove
+// all of the added event handlers.
+func (s *Session) AddHandlers(obj interface{}) func() {
+	v := reflect.ValueOf(obj)
+
+	var removers []func()
+	for i := 0; i < v.NumMethod(); i++ {
+		if eh := s.handlerForInterface(v.Method(i).Interface()); eh != nil {
+			removers = append(removers, s.addEventHandler(eh))
+		}
+	}
+
+	return func() {
+		for _, remove := range removers {
+			remove()
+		}
+	}
+}
+
 // AddHandlerOnce allows you to add an event handler that will be fired the next time
 // the Discord WSAPI event that matches the function fires.
 // See AddHandler for more details.
 func (s *Session) AddHandlerOnce(handler interface{}) func() {
-	eh := handlerForInterface(handler)
+	eh := s.handlerForInterface(handler)
 
 	if eh == nil {
 		s.log(LogError, "Invalid handler type, handler will never be called")
@@ -142,6 +174,102 @@ func (s *Session) AddHandlerOnce(handler interface{}) func() {
 	return s.addEventHandlerOnce(eh)
 }
 
+// RegisterEventType teaches the session about a gateway event which the
+// library does not know yet, so it is unmarshalled into a new value of the
+// template's type and dispatched like any other event.
+// Handlers taking a pointer to the template's type, eg.
+// func(*discordgo.Session, *MyEvent), can be added with AddHandler once the
+// type is registered.
+// name     : The name of the event, eg. "GUILD_SOUNDBOARD_SOUND_CREATE".
+// template : A value or pointer of the struct the event is unmarshalled into.
+//
+// Events already known to the library cannot be registered.
+func (s *Session) RegisterEventType(name string, template interface{}) {
+	if _, ok := registeredInterfaceProviders[name]; ok {
+		s.log(LogError, "event %s is already known, it cannot be registered", name)
+		return
+	}
+
+	t := reflect.TypeOf(template)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		s.log(LogError, "event %s template must be a struct or a pointer to a struct", name)
+		return
+	}
+
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+
+	if s.customEventTypes == nil {
+		s.customEventTypes = map[string]reflect.Type{}
+	}
+	s.customEventTypes[name] = t
+}
+
+// newCustomEvent returns a new value for an event registered with
+// RegisterEventType, or nil if the event was not registered.
+func (s *Session) newCustomEvent(name string) interface{} {
+	s.handlersMu.RLock()
+	defer s.handlersMu.RUnlock()
+
+	t, ok := s.customEventTypes[name]
+	if !ok {
+		return nil
+	}
+	return reflect.New(t).Interface()
+}
+
+// customEventHandler is an event handler for an event registered with
+// RegisterEventType.
+type customEventHandler struct {
+	eventType string
+	fn        reflect.Value
+}
+
+// Type returns the event type for the handler.
+func (eh customEventHandler) Type() string {
+	return eh.eventType
+}
+
+// Handle is the handler for the registered event.
+func (eh customEventHandler) Handle(s *Session, i interface{}) {
+	v := reflect.ValueOf(i)
+	if !v.IsValid() || v.Type() != eh.fn.Type().In(1) {
+		return
+	}
+	eh.fn.Call([]reflect.Value{reflect.ValueOf(s), v})
+}
+
+// handlerForInterface returns the EventHandler for a handler function, which
+// may handle one of the library's events or an event registered with
+// RegisterEventType. It returns nil for invalid handlers.
+func (s *Session) handlerForInterface(handler interface{}) EventHandler {
+	if eh := handlerForInterface(handler); eh != nil {
+		return eh
+	}
+
+	fn := reflect.ValueOf(handler)
+	if !fn.IsValid() {
+		return nil
+	}
+	ft := fn.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 2 || ft.NumOut() != 0 || ft.In(0) != reflect.TypeOf(s) {
+		return nil
+	}
+
+	s.handlersMu.RLock()
+	defer s.handlersMu.RUnlock()
+
+	for name, t := range s.customEventTypes {
+		if ft.In(1) == reflect.PtrTo(t) {
+			return customEventHandler{name, fn}
+		}
+	}
+	return nil
+}
+
 // removeEventHandler instance removes an event handler instance.
 func (s *Session) removeEventHandlerInstance(t string, ehi *eventHandlerInstance) {
 	s.handlersMu.Lock()
@@ -165,25 +293,78 @@ func (s *Session) removeEventHandlerInstance(t string, ehi *eventHandlerInstance
 // Handles calling permanent and once handlers for an event type.
 func (s *Session) handle(t string, i interface{}) {
 	for _, eh := range s.handlers[t] {
-		if s.SyncEvents {
-			eh.eventHandler.Handle(s, i)
-		} else {
-			go eh.eventHandler.Handle(s, i)
-		}
+		s.callHandler(eh, i)
 	}
 
 	if len(s.onceHandlers[t]) > 0 {
 		for _, eh := range s.onceHandlers[t] {
-			if s.SyncEvents {
-				eh.eventHandler.Handle(s, i)
-			} else {
-				go eh.eventHandler.Handle(s, i)
-			}
+			s.callHandler(eh, i)
 		}
 		s.onceHandlers[t] = nil
 	}
 }
 
+// callHandler calls an event handler, in its own goroutine unless SyncEvents
+// is set. Asynchronous handlers are tracked so CloseGracefully can wait for them.
+// It must be called with handlersMu held.
+func (s *Session) callHandler(eh *eventHandlerInstance, i interface{}) {
+	if s.SyncEvents {
+		eh.eventHandler.Handle(s, i)
+		return
+	}
+
+	// No handlers are started while CloseGracefully waits for the running ones.
+	if s.handlersClosing > 0 {
+		return
+	}
+
+	s.handlersWg.Add(1)
+	go func() {
+		defer s.handlersWg.Done()
+		eh.eventHandler.Handle(s, i)
+	}()
+}
+
+// middlewareInstance is a wrapper around an event middleware, as functions
+// cannot be compared directly.
+type middlewareInstance struct {
+	middleware func(*Session, interface{}) bool
+}
+
+// AddMiddleware adds a middleware which is called with every event before it
+// is dispatched to any handler. Middlewares are called in the order they were
+// added; if one returns false, the event is not passed to the remaining
+// middlewares nor to any handler.
+// Internal state tracking is not affected by middlewares.
+//
+// eg:
+//     Session.AddMiddleware(func(s *discordgo.Session, e interface{}) bool {
+//         m, ok := e.(*discordgo.MessageCreate)
+//         return !ok || !m.Author.Bot
+//     })
+//
+// The return value of this method is a function, that when called will remove the
+// middleware.
+func (s *Session) AddMiddleware(middleware func(s *Session, e interface{}) bool) func() {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+
+	mi := &middlewareInstance{middleware}
+	s.middlewares = append(s.middlewares, mi)
+
+	return func() {
+		s.handlersMu.Lock()
+		defer s.handlersMu.Unlock()
+
+		for i := range s.middlewares {
+			if s.middlewares[i] == mi {
+				s.middlewares = append(s.middlewares[:i:i], s.middlewares[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
 // Handles an event type by calling internal methods, firing handlers and firing the
 // interface{} event.
 func (s *Session) handleEvent(t string, i interface{}) {
@@ -193,6 +374,13 @@ func (s *Session) handleEvent(t string, i interface{}) {
 	// All events are dispatched internally first.
 	s.onInterface(i)
 
+	// Then they pass through the middlewares, which may stop them.
+	for _, mi := range s.middlewares {
+		if !mi.middleware(s, i) {
+			return
+		}
+	}
+
 	// Then they are dispatched to anyone handling interface{} events.
 	s.handle(interfaceEventType, i)
 
@@ -228,6 +416,10 @@ func (s *Session) onInterface(i interface{}) {
 		setGuildIds(t.Guild)
 	case *GuildUpdate:
 		setGuildIds(t.Guild)
+	case *MessageCreate:
+		if s.TrackSentNonces {
+			s.trackNonce(t.Message)
+		}
 	case *VoiceServerUpdate:
 		go s.onVoiceServerUpdate(t)
 	case *VoiceStateUpdate:
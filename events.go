@@ -17,7 +17,20 @@ type Connect struct{}
 // This is a synthetic event and is not dispatched by Discord.
 type Disconnect struct{}
 
+// Reconnect is the data for a Reconnect event, emitted after Connect when the
+// session reconnected to the gateway after losing its connection.
+// If the previous session could not be resumed, events sent while
+// disconnected were lost and any cached data should be reconciled.
+// This is a synthetic event and is not dispatched by Discord.
+type Reconnect struct {
+	// Whether the previous session was resumed.
+	Resumed bool
+}
+
 // RateLimit is the data for a RateLimit event.
+// It is emitted when a REST request hits a 429 response, and when a request
+// has to wait for its exhausted ratelimit bucket to reset before being sent.
+// In the latter case Bucket holds the bucket ID and RetryAfter the wait.
 // This is a synthetic event and is not dispatched by Discord.
 type RateLimit struct {
 	*TooManyRequests
@@ -126,6 +139,9 @@ type GuildUpdate struct {
 }
 
 // GuildDelete is the data for a GuildDelete event.
+// Unavailable is true when the guild became unavailable because of an
+// outage, in which case it is kept in the State, and false when the
+// session user was removed from the guild or left it.
 type GuildDelete struct {
 	*Guild
 	BeforeDelete *Guild `json:"-"`
@@ -336,6 +352,13 @@ type UserUpdate struct {
 	*User
 }
 
+// VoiceChannelStatusUpdate is the data for a VoiceChannelStatusUpdate event.
+type VoiceChannelStatusUpdate struct {
+	ID      string `json:"id"`
+	GuildID string `json:"guild_id"`
+	Status  string `json:"status"`
+}
+
 // VoiceServerUpdate is the data for a VoiceServerUpdate event.
 type VoiceServerUpdate struct {
 	Token    string `json:"token"`
@@ -80,9 +80,9 @@ func main() {
 	}
 
 	// Now lets format our base64 image into the proper format Discord wants
-	// and then call UserUpdate to set it as our user's Avatar.
+	// and then call UserUpdateComplex to set it as our user's Avatar.
 	avatar := fmt.Sprintf("data:%s;base64,%s", contentType, base64img)
-	_, err = dg.UserUpdate("", avatar, "")
+	_, err = dg.UserUpdateComplex(&discordgo.UserUpdateParams{Avatar: &avatar})
 	if err != nil {
 		fmt.Println(err)
 	}
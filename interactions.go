@@ -137,6 +137,149 @@ type ApplicationCommandOptionChoice struct {
 	Value             interface{}       `json:"value"`
 }
 
+// ApplicationCommandMaxOptions is the maximum number of options a command,
+// subcommand group or subcommand may have.
+const ApplicationCommandMaxOptions = 25
+
+// NewApplicationCommandOption returns a regular (non-subcommand) option.
+// t           : The type of the option.
+// name        : The name of the option.
+// description : The description of the option.
+// required    : Whether the option must be provided.
+func NewApplicationCommandOption(t ApplicationCommandOptionType, name, description string, required bool) *ApplicationCommandOption {
+	return &ApplicationCommandOption{
+		Type:        t,
+		Name:        name,
+		Description: description,
+		Required:    required,
+	}
+}
+
+// NewSubCommand returns a subcommand option holding the given options.
+// name        : The name of the subcommand.
+// description : The description of the subcommand.
+// options     : The options of the subcommand.
+func NewSubCommand(name, description string, options ...*ApplicationCommandOption) *ApplicationCommandOption {
+	return &ApplicationCommandOption{
+		Type:        ApplicationCommandOptionSubCommand,
+		Name:        name,
+		Description: description,
+		Options:     options,
+	}
+}
+
+// NewSubCommandGroup returns a subcommand group holding the given subcommands.
+// name        : The name of the group.
+// description : The description of the group.
+// subcommands : The subcommands of the group.
+func NewSubCommandGroup(name, description string, subcommands ...*ApplicationCommandOption) *ApplicationCommandOption {
+	return &ApplicationCommandOption{
+		Type:        ApplicationCommandOptionSubCommandGroup,
+		Name:        name,
+		Description: description,
+		Options:     subcommands,
+	}
+}
+
+// AddOptions appends options to the command and returns the command.
+func (c *ApplicationCommand) AddOptions(options ...*ApplicationCommandOption) *ApplicationCommand {
+	c.Options = append(c.Options, options...)
+	return c
+}
+
+// AddOptions appends options to the option and returns the option.
+func (o *ApplicationCommandOption) AddOptions(options ...*ApplicationCommandOption) *ApplicationCommandOption {
+	o.Options = append(o.Options, options...)
+	return o
+}
+
+// AddChoice appends a choice to the option and returns the option.
+func (o *ApplicationCommandOption) AddChoice(name string, value interface{}) *ApplicationCommandOption {
+	o.Choices = append(o.Choices, &ApplicationCommandOptionChoice{Name: name, Value: value})
+	return o
+}
+
+// Validate checks that the option tree of the command follows Discord's
+// nesting rules: a level may hold either subcommands/groups or regular
+// options but not both, groups may only appear at the top level and only
+// contain subcommands, subcommands may only contain regular options, and
+// no level may hold more than ApplicationCommandMaxOptions options.
+func (c *ApplicationCommand) Validate() error {
+	if c.Type != 0 && c.Type != ChatApplicationCommand {
+		if len(c.Options) != 0 {
+			return fmt.Errorf("command %q: only chat commands may have options", c.Name)
+		}
+		return nil
+	}
+	return validateCommandOptions(c.Name, c.Options, 0)
+}
+
+// validateCommandOptions validates a single level of an option tree.
+// depth is 0 for the command's own options, 1 inside a subcommand group or
+// subcommand, and 2 inside a subcommand of a group.
+func validateCommandOptions(path string, options []*ApplicationCommandOption, depth int) error {
+	if len(options) > ApplicationCommandMaxOptions {
+		return fmt.Errorf("%s: %d options exceeds the maximum of %d", path, len(options), ApplicationCommandMaxOptions)
+	}
+
+	var subcommands, regular int
+	for _, o := range options {
+		if o == nil {
+			return fmt.Errorf("%s: nil option", path)
+		}
+		switch o.Type {
+		case ApplicationCommandOptionSubCommand, ApplicationCommandOptionSubCommandGroup:
+			subcommands++
+		default:
+			regular++
+		}
+	}
+	if subcommands > 0 && regular > 0 {
+		return fmt.Errorf("%s: subcommands cannot be mixed with other options", path)
+	}
+
+	for _, o := range options {
+		p := path + " " + o.Name
+		switch o.Type {
+		case ApplicationCommandOptionSubCommandGroup:
+			if depth != 0 {
+				return fmt.Errorf("%s: subcommand groups are only allowed at the top level", p)
+			}
+			if len(o.Options) == 0 {
+				return fmt.Errorf("%s: subcommand group has no subcommands", p)
+			}
+			for _, s := range o.Options {
+				if s != nil && s.Type != ApplicationCommandOptionSubCommand {
+					return fmt.Errorf("%s: subcommand groups may only contain subcommands", p)
+				}
+			}
+			if err := validateCommandOptions(p, o.Options, depth+1); err != nil {
+				return err
+			}
+		case ApplicationCommandOptionSubCommand:
+			if depth > 1 {
+				return fmt.Errorf("%s: subcommands nested too deeply", p)
+			}
+			for _, s := range o.Options {
+				if s != nil && (s.Type == ApplicationCommandOptionSubCommand || s.Type == ApplicationCommandOptionSubCommandGroup) {
+					return fmt.Errorf("%s: subcommands may not contain subcommands", p)
+				}
+			}
+			if err := validateCommandOptions(p, o.Options, 2); err != nil {
+				return err
+			}
+		default:
+			if len(o.Options) != 0 {
+				return fmt.Errorf("%s: %s options cannot have nested options", p, o.Type)
+			}
+			if len(o.Choices) > ApplicationCommandMaxOptions {
+				return fmt.Errorf("%s: %d choices exceeds the maximum of %d", p, len(o.Choices), ApplicationCommandMaxOptions)
+			}
+		}
+	}
+	return nil
+}
+
 // ApplicationCommandPermissions represents a single user or role permission for a command.
 type ApplicationCommandPermissions struct {
 	ID         string                           `json:"id"`
@@ -66,3 +66,41 @@ func TestVerifyInteraction(t *testing.T) {
 		}
 	})
 }
+
+func TestApplicationCommand_Validate(t *testing.T) {
+	str := func(name string) *ApplicationCommandOption {
+		return NewApplicationCommandOption(ApplicationCommandOptionString, name, "desc", false)
+	}
+
+	tooMany := make([]*ApplicationCommandOption, ApplicationCommandMaxOptions+1)
+	for i := range tooMany {
+		tooMany[i] = str("o" + strconv.Itoa(i))
+	}
+
+	tests := []struct {
+		name    string
+		command *ApplicationCommand
+		valid   bool
+	}{
+		{"flat", (&ApplicationCommand{Name: "cmd"}).AddOptions(str("a"), str("b")), true},
+		{"subcommands", (&ApplicationCommand{Name: "cmd"}).AddOptions(NewSubCommand("sub", "desc", str("a"))), true},
+		{"group", (&ApplicationCommand{Name: "cmd"}).AddOptions(NewSubCommandGroup("grp", "desc", NewSubCommand("sub", "desc", str("a")))), true},
+		{"mixed", (&ApplicationCommand{Name: "cmd"}).AddOptions(NewSubCommand("sub", "desc"), str("a")), false},
+		{"nested group", (&ApplicationCommand{Name: "cmd"}).AddOptions(NewSubCommandGroup("grp", "desc", NewSubCommandGroup("inner", "desc"))), false},
+		{"subcommand in subcommand", (&ApplicationCommand{Name: "cmd"}).AddOptions(NewSubCommand("sub", "desc", NewSubCommand("inner", "desc"))), false},
+		{"options in option", (&ApplicationCommand{Name: "cmd"}).AddOptions(str("a").AddOptions(str("b"))), false},
+		{"too many", (&ApplicationCommand{Name: "cmd"}).AddOptions(tooMany...), false},
+		{"too many in subcommand", (&ApplicationCommand{Name: "cmd"}).AddOptions(NewSubCommand("sub", "desc", tooMany...)), false},
+		{"user command options", (&ApplicationCommand{Name: "cmd", Type: UserApplicationCommand}).AddOptions(str("a")), false},
+	}
+
+	for _, tt := range tests {
+		err := tt.command.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
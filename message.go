@@ -11,10 +11,14 @@ package discordgo
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"os"
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // MessageType is the type of Message
@@ -161,6 +165,14 @@ type Message struct {
 
 	// A poll object.
 	Poll *Poll `json:"poll"`
+
+	// The nonce the message was sent with, if any.
+	// It is only sent with the MessageCreate event of the message.
+	Nonce string `json:"nonce,omitempty"`
+
+	// The approximate position of the message in a thread.
+	// It can be used to order messages within a thread.
+	Position *int `json:"position,omitempty"`
 }
 
 // UnmarshalJSON is a helper function to unmarshal the Message.
@@ -169,12 +181,20 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 	var v struct {
 		message
 		RawComponents []unmarshalableMessageComponent `json:"components"`
+		// The nonce is either a string or an integer.
+		RawNonce json.RawMessage `json:"nonce"`
 	}
 	err := json.Unmarshal(data, &v)
 	if err != nil {
 		return err
 	}
 	*m = Message(v.message)
+	if len(v.RawNonce) > 0 && string(v.RawNonce) != "null" {
+		if err = json.Unmarshal(v.RawNonce, &m.Nonce); err != nil {
+			m.Nonce = string(v.RawNonce)
+			err = nil
+		}
+	}
 	m.Components = make([]MessageComponent, len(v.RawComponents))
 	for i, v := range v.RawComponents {
 		m.Components[i] = v.MessageComponent
@@ -200,6 +220,115 @@ func (m *Message) GetCustomEmojis() []*Emoji {
 	return toReturn
 }
 
+// EmojisInString returns all emojis found in content, in order of appearance.
+// Custom emojis (<:name:id> and <a:name:id>) are returned with their ID, Name
+// and Animated fields set, unicode emojis (including skin tone, ZWJ, flag and
+// keycap sequences) are returned with only their Name set, which is the form
+// expected by the MessageReactions endpoints.
+func EmojisInString(content string) (emojis []*Emoji) {
+	last := 0
+	for _, loc := range EmojiRegex.FindAllStringIndex(content, -1) {
+		emojis = append(emojis, unicodeEmojis(content[last:loc[0]])...)
+
+		em := content[loc[0]:loc[1]]
+		parts := strings.Split(em, ":")
+		emojis = append(emojis, &Emoji{
+			ID:       parts[2][:len(parts[2])-1],
+			Name:     parts[1],
+			Animated: strings.HasPrefix(em, "<a:"),
+		})
+		last = loc[1]
+	}
+
+	return append(emojis, unicodeEmojis(content[last:])...)
+}
+
+// unicodeEmojis returns the unicode emoji sequences found in s.
+func unicodeEmojis(s string) (emojis []*Emoji) {
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		start := i
+		r := rs[i]
+
+		switch {
+		case strings.ContainsRune("0123456789#*", r):
+			// Keycap sequence, e.g. 1️⃣
+			j := i + 1
+			if j < len(rs) && rs[j] == '\uFE0F' {
+				j++
+			}
+			if j < len(rs) && rs[j] == '\u20E3' {
+				i = j + 1
+			} else {
+				i++
+				continue
+			}
+		case isRegionalIndicator(r):
+			// Flags are made of two regional indicators.
+			if i+1 < len(rs) && isRegionalIndicator(rs[i+1]) {
+				i += 2
+			} else {
+				i++
+				continue
+			}
+		case isEmojiRune(r) || (isTextDefaultEmojiRune(r) && i+1 < len(rs) && rs[i+1] == '\uFE0F'):
+			i++
+			for i < len(rs) {
+				if isEmojiModifier(rs[i]) {
+					i++
+				} else if rs[i] == '\u200D' && i+1 < len(rs) && isEmojiRune(rs[i+1]) {
+					i += 2
+				} else {
+					break
+				}
+			}
+		default:
+			i++
+			continue
+		}
+
+		emojis = append(emojis, &Emoji{Name: string(rs[start:i])})
+	}
+
+	return
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isEmojiModifier reports whether r modifies the preceding emoji: variation
+// selector 16, skin tones and tag characters used by subdivision flags.
+func isEmojiModifier(r rune) bool {
+	return r == 0xFE0F || (r >= 0x1F3FB && r <= 0x1F3FF) || (r >= 0xE0020 && r <= 0xE007F)
+}
+
+// isTextDefaultEmojiRune reports whether r is only displayed as an emoji
+// when followed by variation selector 16, e.g. © or ™.
+func isTextDefaultEmojiRune(r rune) bool {
+	switch {
+	case r == 0x00A9, r == 0x00AE, r == 0x203C, r == 0x2049, r == 0x2122, r == 0x2139:
+		return true
+	case r >= 0x2194 && r <= 0x21AA:
+		return true
+	}
+	return false
+}
+
+func isEmojiRune(r rune) bool {
+	switch {
+	case isRegionalIndicator(r):
+		return false
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return true
+	case r >= 0x2300 && r <= 0x23FF, r >= 0x2600 && r <= 0x27BF, r >= 0x2B00 && r <= 0x2BFF:
+		return true
+	case r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	}
+	return false
+}
+
 // MessageFlags is the flags of "message" (see MessageFlags* consts)
 // https://discord.com/developers/docs/resources/channel#message-object-message-flags
 type MessageFlags int
@@ -252,6 +381,14 @@ type MessageSend struct {
 	Flags           MessageFlags            `json:"flags,omitempty"`
 	Poll            *Poll                   `json:"poll,omitempty"`
 
+	// A nonce which can be used to verify the message was sent.
+	// With EnforceNonce set, Discord deduplicates messages with the same
+	// nonce, and ChannelMessageSendComplex retries once on connection errors.
+	// Both are set on messages sent without a nonce when
+	// Session.TrackSentNonces is set.
+	Nonce        string `json:"nonce,omitempty"`
+	EnforceNonce bool   `json:"enforce_nonce,omitempty"`
+
 	// TODO: Remove this when compatibility is not required.
 	File *File `json:"-"`
 
@@ -259,6 +396,112 @@ type MessageSend struct {
 	Embed *MessageEmbed `json:"-"`
 }
 
+// Limits enforced by MessageSend.Validate.
+const (
+	MessageMaxContentLength = 2000
+	MessageMaxEmbeds        = 10
+	MessageMaxEmbedsLength  = 6000
+	MessageMaxActionRows    = 5
+	MessageMaxFiles         = 10
+	MessageMaxStickers      = 3
+)
+
+// Validate checks the message against Discord's limits on the content,
+// embeds, components, stickers and files, so that a message which would be
+// rejected fails locally with a descriptive error.
+// File sizes are not checked, as the upload limit depends on the guild,
+// see ValidateFileSizes.
+func (m *MessageSend) Validate() error {
+	if n := utf8.RuneCountInString(m.Content); n > MessageMaxContentLength {
+		return fmt.Errorf("message content is %d characters long, the maximum is %d", n, MessageMaxContentLength)
+	}
+
+	embeds := m.Embeds
+	if m.Embed != nil && m.Embeds == nil {
+		embeds = []*MessageEmbed{m.Embed}
+	}
+	if len(embeds) > MessageMaxEmbeds {
+		return fmt.Errorf("message has %d embeds, the maximum is %d", len(embeds), MessageMaxEmbeds)
+	}
+	total := 0
+	for _, e := range embeds {
+		if e != nil {
+			total += e.textLength()
+		}
+	}
+	if total > MessageMaxEmbedsLength {
+		return fmt.Errorf("message embeds have %d characters in total, the maximum is %d", total, MessageMaxEmbedsLength)
+	}
+
+	if len(m.Components) > MessageMaxActionRows {
+		return fmt.Errorf("message has %d component rows, the maximum is %d", len(m.Components), MessageMaxActionRows)
+	}
+
+	if len(m.StickerIDs) > MessageMaxStickers {
+		return fmt.Errorf("cannot send more than %d stickers", MessageMaxStickers)
+	}
+
+	if files := m.allFiles(); len(files) > MessageMaxFiles {
+		return fmt.Errorf("message has %d files, the maximum is %d", len(files), MessageMaxFiles)
+	}
+
+	return nil
+}
+
+// ValidateFileSizes checks that no file of the message is larger than max
+// bytes. The size of a file is only checked when its Reader reports one,
+// like *bytes.Reader, *bytes.Buffer, *strings.Reader and *os.File do.
+func (m *MessageSend) ValidateFileSizes(max int64) error {
+	for _, f := range m.allFiles() {
+		if size, ok := readerSize(f.Reader); ok && size > max {
+			return fmt.Errorf("file %s is %d bytes, the maximum is %d", f.Name, size, max)
+		}
+	}
+	return nil
+}
+
+// allFiles returns the files of the message, including the legacy File.
+func (m *MessageSend) allFiles() []*File {
+	files := m.Files
+	if m.File != nil {
+		files = append(files[:len(files):len(files)], m.File)
+	}
+	return files
+}
+
+// readerSize returns the number of bytes left in r, if r can report it.
+func readerSize(r io.Reader) (int64, bool) {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len()), true
+	case interface{ Stat() (os.FileInfo, error) }:
+		fi, err := v.Stat()
+		if err != nil {
+			return 0, false
+		}
+		return fi.Size(), true
+	}
+	return 0, false
+}
+
+// textLength returns the number of characters of the embed which count
+// towards MessageMaxEmbedsLength.
+func (e *MessageEmbed) textLength() int {
+	n := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+	for _, f := range e.Fields {
+		if f != nil {
+			n += utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+		}
+	}
+	if e.Footer != nil {
+		n += utf8.RuneCountInString(e.Footer.Text)
+	}
+	if e.Author != nil {
+		n += utf8.RuneCountInString(e.Author.Name)
+	}
+	return n
+}
+
 // MessageEdit is used to chain parameters via ChannelMessageEditComplex, which
 // is also where you should get the instance from.
 type MessageEdit struct {
@@ -419,7 +662,7 @@ type MessageEmbed struct {
 	Title       string                 `json:"title,omitempty"`
 	Description string                 `json:"description,omitempty"`
 	Timestamp   string                 `json:"timestamp,omitempty"`
-	Color       int                    `json:"color,omitempty"`
+	Color       int                    `json:"color,omitempty"` // Use EmbedColorBlack for 0x000000, as 0 is omitted.
 	Footer      *MessageEmbedFooter    `json:"footer,omitempty"`
 	Image       *MessageEmbedImage     `json:"image,omitempty"`
 	Thumbnail   *MessageEmbedThumbnail `json:"thumbnail,omitempty"`
@@ -429,6 +672,39 @@ type MessageEmbed struct {
 	Fields      []*MessageEmbedField   `json:"fields,omitempty"`
 }
 
+// EmbedColorBlack is a MessageEmbed.Color value which is sent as a black
+// (0x000000) color. A zero Color is omitted and shown as the default color.
+const EmbedColorBlack = -1
+
+// MarshalJSON is a method for marshaling MessageEmbed to a JSON object.
+func (e MessageEmbed) MarshalJSON() ([]byte, error) {
+	type messageEmbed MessageEmbed
+
+	if e.Color != EmbedColorBlack {
+		return Marshal(messageEmbed(e))
+	}
+
+	return Marshal(struct {
+		messageEmbed
+		Color int `json:"color"`
+	}{
+		messageEmbed: messageEmbed(e),
+		Color:        0,
+	})
+}
+
+// SetTimestamp sets the timestamp shown in the footer of the embed.
+// t : The time to show, it is sent in UTC.
+func (e *MessageEmbed) SetTimestamp(t time.Time) *MessageEmbed {
+	e.Timestamp = t.UTC().Format(time.RFC3339)
+	return e
+}
+
+// SetTimestampNow sets the timestamp shown in the footer of the embed to the current time.
+func (e *MessageEmbed) SetTimestampNow() *MessageEmbed {
+	return e.SetTimestamp(time.Now())
+}
+
 // EmbedType is the type of embed
 // https://discord.com/developers/docs/resources/channel#embed-object-embed-types
 type EmbedType string
@@ -522,6 +798,22 @@ func (m *Message) SoftReference() *MessageReference {
 	return m.reference(MessageReferenceTypeDefault, false)
 }
 
+// Link returns a link to the message, which uses @me in place of the
+// guild ID for messages in private channels.
+func (m *Message) Link() string {
+	guildID := m.GuildID
+	if guildID == "" {
+		guildID = "@me"
+	}
+	return EndpointMessageLink(guildID, m.ChannelID, m.ID)
+}
+
+// HasFlag returns whether all of the given flags are set on the message,
+// e.g. MessageFlagsEphemeral or MessageFlagsLoading.
+func (m *Message) HasFlag(flag MessageFlags) bool {
+	return m.Flags&flag == flag
+}
+
 // Forward returns a MessageReference for a forwarded message.
 func (m *Message) Forward() *MessageReference {
 	return m.reference(MessageReferenceTypeForward, true)
@@ -541,8 +833,128 @@ func (m *Message) ContentWithMentionsReplaced() (content string) {
 	return
 }
 
+// IsCommand checks whether the message content starts with prefix directly
+// followed by a command name. If it does, the command and its arguments are
+// returned with ok set to true.
+// Arguments are separated by whitespace; double quotes group words into one
+// argument and a backslash escapes the next character, e.g.
+//
+//	!ban "Some User" spamming\ links
+//
+// returns "ban" and ["Some User", "spamming links"] for the prefix "!".
+func (m *Message) IsCommand(prefix string) (cmd string, args []string, ok bool) {
+	if !strings.HasPrefix(m.Content, prefix) {
+		return
+	}
+
+	content := m.Content[len(prefix):]
+	if content == "" || unicode.IsSpace([]rune(content)[0]) {
+		return
+	}
+
+	tokens := splitArguments(content)
+	if len(tokens) == 0 {
+		return
+	}
+
+	return tokens[0], tokens[1:], true
+}
+
+// splitArguments splits s on whitespace, keeping double quoted sections
+// together and unescaping backslash escaped characters.
+func splitArguments(s string) (tokens []string) {
+	var (
+		token   strings.Builder
+		inToken bool
+		quoted  bool
+		escaped bool
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			token.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			inToken = true
+			escaped = true
+		case r == '"':
+			inToken = true
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			inToken = true
+			token.WriteRune(r)
+		}
+	}
+
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+
+	return
+}
+
 var patternChannels = regexp.MustCompile("<#[^>]*>")
 
+// MentionedRoles returns the roles mentioned in the message, in the order
+// of MentionRoles. Roles are looked up in the State when it is enabled, and
+// the roles of the guild are fetched when one of them is not cached.
+// Mentioned roles which no longer exist are left out.
+func (m *Message) MentionedRoles(s *Session) (roles []*Role, err error) {
+	if len(m.MentionRoles) == 0 {
+		return
+	}
+
+	guildID := m.GuildID
+	if guildID == "" {
+		var channel *Channel
+		channel, err = s.State.Channel(m.ChannelID)
+		if err != nil {
+			channel, err = s.Channel(m.ChannelID)
+			if err != nil {
+				return
+			}
+		}
+		guildID = channel.GuildID
+	}
+
+	if s.StateEnabled {
+		for _, roleID := range m.MentionRoles {
+			role, err := s.State.Role(guildID, roleID)
+			if err != nil {
+				roles = nil
+				break
+			}
+			roles = append(roles, role)
+		}
+		if roles != nil {
+			return
+		}
+	}
+
+	guildRoles, err := s.GuildRoles(guildID)
+	if err != nil {
+		return
+	}
+
+	byID := make(map[string]*Role, len(guildRoles))
+	for _, role := range guildRoles {
+		byID[role.ID] = role
+	}
+	for _, roleID := range m.MentionRoles {
+		if role, ok := byID[roleID]; ok {
+			roles = append(roles, role)
+		}
+	}
+	return
+}
+
 // ContentWithMoreMentionsReplaced will replace all @<id> mentions with the
 // username of the mention, but also role IDs and more.
 func (m *Message) ContentWithMoreMentionsReplaced(s *Session) (content string, err error) {
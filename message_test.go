@@ -1,7 +1,12 @@
 package discordgo
 
 import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestContentWithMoreMentionsReplaced(t *testing.T) {
@@ -51,6 +56,30 @@ func TestGettingEmojisFromMessage(t *testing.T) {
 
 }
 
+func TestEmojisInString(t *testing.T) {
+	content := "role <a:dance:811736565172011058> 👍🏽 and <:kitty:811736468812595260>, " +
+		"👨\u200D👩\u200D👧 🇫🇷 1\uFE0F\u20E3 ❤\uFE0F © 12 :)"
+	want := []Emoji{
+		{ID: "811736565172011058", Name: "dance", Animated: true},
+		{Name: "👍🏽"},
+		{ID: "811736468812595260", Name: "kitty"},
+		{Name: "👨\u200D👩\u200D👧"},
+		{Name: "🇫🇷"},
+		{Name: "1\uFE0F\u20E3"},
+		{Name: "❤\uFE0F"},
+	}
+
+	got := EmojisInString(content)
+	if len(got) != len(want) {
+		t.Fatalf("EmojisInString() returned %d emojis, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].Name != want[i].Name || got[i].Animated != want[i].Animated {
+			t.Errorf("emoji %d = %+v, want %+v", i, *got[i], want[i])
+		}
+	}
+}
+
 func TestMessage_Reference(t *testing.T) {
 	m := &Message{
 		ID:        "811736565172011001",
@@ -103,9 +132,219 @@ func TestMessage_Forward(t *testing.T) {
 	}
 }
 
+func TestMessage_Link(t *testing.T) {
+	m := &Message{ID: "3", ChannelID: "2", GuildID: "1"}
+	if got, want := m.Link(), "https://discord.com/channels/1/2/3"; got != want {
+		t.Errorf("Link() = %q, want %q", got, want)
+	}
+
+	m.GuildID = ""
+	if got, want := m.Link(), "https://discord.com/channels/@me/2/3"; got != want {
+		t.Errorf("Link() in DM = %q, want %q", got, want)
+	}
+}
+
 func TestMessageReference_DefaultTypeIsDefault(t *testing.T) {
 	r := MessageReference{}
 	if r.Type != MessageReferenceTypeDefault {
 		t.Error("Default message type should be MessageReferenceTypeDefault")
 	}
 }
+
+func TestMessage_IsCommand(t *testing.T) {
+	tests := []struct {
+		content string
+		cmd     string
+		args    []string
+		ok      bool
+	}{
+		{"!ping", "ping", []string{}, true},
+		{"!ban  \"Some User\"   spamming\\ links", "ban", []string{"Some User", "spamming links"}, true},
+		{"!say \"a \\\"quoted\\\" word\" \"\"", "say", []string{"a \"quoted\" word", ""}, true},
+		{"!say \"unterminated quote", "say", []string{"unterminated quote"}, true},
+		{"! ping", "", nil, false},
+		{"!", "", nil, false},
+		{"ping", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		m := &Message{Content: tt.content}
+		cmd, args, ok := m.IsCommand("!")
+		if ok != tt.ok || cmd != tt.cmd || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("IsCommand(%q) = %q, %q, %v, want %q, %q, %v", tt.content, cmd, args, ok, tt.cmd, tt.args, tt.ok)
+		}
+	}
+}
+
+func TestMessageSendTTSSerialization(t *testing.T) {
+	data, err := Marshal(&MessageSend{Content: "content"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"tts":false`) {
+		t.Errorf("MessageSend should always send tts, got %s", data)
+	}
+
+	data, err = Marshal(&WebhookParams{Content: "content"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"tts"`) {
+		t.Errorf("WebhookParams should omit tts when false, got %s", data)
+	}
+}
+
+func TestMessageEmbedColorMarshal(t *testing.T) {
+	tests := []struct {
+		color int
+		want  string
+	}{
+		{0, `{"title":"t"}`},
+		{0xFF0000, `{"title":"t","color":16711680}`},
+		{EmbedColorBlack, `{"title":"t","color":0}`},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(&MessageEmbed{Title: "t", Color: tt.color})
+		if err != nil {
+			t.Fatalf("json.Marshal returned error: %+v", err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("Color %d marshaled to %s, want %s", tt.color, b, tt.want)
+		}
+	}
+}
+
+func TestMessageEmbedRoundTrip(t *testing.T) {
+	fixture := `{"url":"https://example.com","type":"rich","title":"Title","description":"Description",` +
+		`"timestamp":"2021-01-01T00:00:00Z","color":3447003,"footer":{"text":"Footer"},` +
+		`"image":{"url":"https://example.com/i.png"},"author":{"name":"Author"},` +
+		`"fields":[{"name":"Field","value":"Value","inline":true}]}`
+
+	var embed MessageEmbed
+	if err := json.Unmarshal([]byte(fixture), &embed); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %+v", err)
+	}
+	b, err := json.Marshal(embed)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %+v", err)
+	}
+	if string(b) != fixture {
+		t.Errorf("round trip mismatch:\n got %s\nwant %s", b, fixture)
+	}
+}
+
+func TestMessageEmbed_SetTimestamp(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	e := (&MessageEmbed{}).SetTimestamp(time.Date(2024, 3, 1, 14, 30, 15, 123456789, loc))
+	if want := "2024-03-01T12:30:15Z"; e.Timestamp != want {
+		t.Errorf("Timestamp = %q, want %q", e.Timestamp, want)
+	}
+
+	e.SetTimestampNow()
+	ts, err := time.Parse(time.RFC3339, e.Timestamp)
+	if err != nil {
+		t.Fatalf("SetTimestampNow produced an invalid timestamp %q: %v", e.Timestamp, err)
+	}
+	if d := time.Since(ts); d < 0 || d > time.Minute {
+		t.Errorf("SetTimestampNow timestamp %v is not the current time", ts)
+	}
+}
+
+func TestMessageSend_Validate(t *testing.T) {
+	embeds := func(n int) []*MessageEmbed {
+		e := make([]*MessageEmbed, n)
+		for i := range e {
+			e[i] = &MessageEmbed{Title: "title"}
+		}
+		return e
+	}
+	rows := func(n int) []MessageComponent {
+		r := make([]MessageComponent, n)
+		for i := range r {
+			r[i] = ActionsRow{}
+		}
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		m     *MessageSend
+		valid bool
+	}{
+		{"empty", &MessageSend{}, true},
+		{"content at limit", &MessageSend{Content: strings.Repeat("é", MessageMaxContentLength)}, true},
+		{"content too long", &MessageSend{Content: strings.Repeat("a", MessageMaxContentLength+1)}, false},
+		{"too many embeds", &MessageSend{Embeds: embeds(MessageMaxEmbeds + 1)}, false},
+		{"embeds too long", &MessageSend{Embeds: []*MessageEmbed{
+			{Description: strings.Repeat("a", 4000)},
+			{Fields: []*MessageEmbedField{{Name: "n", Value: strings.Repeat("a", 2000)}}},
+		}}, false},
+		{"too many rows", &MessageSend{Components: rows(MessageMaxActionRows + 1)}, false},
+		{"too many stickers", &MessageSend{StickerIDs: []string{"1", "2", "3", "4"}}, false},
+		{"too many files", &MessageSend{Files: make([]*File, MessageMaxFiles+1)}, false},
+		{"large file", &MessageSend{Files: []*File{{Name: "a.txt", Reader: strings.NewReader(strings.Repeat("a", 11<<20))}}}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.m.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestMessageNonceUnmarshal(t *testing.T) {
+	for data, want := range map[string]string{
+		`{"id": "1", "nonce": "abc"}`:               "abc",
+		`{"id": "1", "nonce": 1234567890123456789}`: "1234567890123456789",
+		`{"id": "1", "nonce": null}`:                "",
+		`{"id": "1"}`:                               "",
+	} {
+		var m Message
+		if err := json.Unmarshal([]byte(data), &m); err != nil {
+			t.Errorf("Unmarshal(%s) returned error: %v", data, err)
+			continue
+		}
+		if m.Nonce != want {
+			t.Errorf("Unmarshal(%s) nonce = %q, want %q", data, m.Nonce, want)
+		}
+	}
+}
+
+func TestMessage_HasFlag(t *testing.T) {
+	var m Message
+	if err := json.Unmarshal([]byte(`{"id": "1", "flags": 192}`), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.HasFlag(MessageFlagsEphemeral) || !m.HasFlag(MessageFlagsLoading) {
+		t.Errorf("flags %d should be ephemeral and loading", m.Flags)
+	}
+	if !m.HasFlag(MessageFlagsEphemeral | MessageFlagsLoading) {
+		t.Errorf("flags %d should have both flags", m.Flags)
+	}
+	if m.HasFlag(MessageFlagsUrgent) || m.HasFlag(MessageFlagsEphemeral|MessageFlagsUrgent) {
+		t.Errorf("flags %d should not be urgent", m.Flags)
+	}
+}
+
+func TestMessageSend_ValidateFileSizes(t *testing.T) {
+	tests := []struct {
+		name  string
+		m     *MessageSend
+		valid bool
+	}{
+		{"small file", &MessageSend{Files: []*File{{Name: "a.txt", Reader: strings.NewReader("abcd")}}}, true},
+		{"large file", &MessageSend{Files: []*File{{Name: "a.txt", Reader: bytes.NewReader([]byte("abcde"))}}}, false},
+		{"legacy large file", &MessageSend{File: &File{Name: "a.txt", Reader: bytes.NewBufferString("abcde")}}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.m.ValidateFileSizes(4); (err == nil) != tt.valid {
+			t.Errorf("%s: ValidateFileSizes(4) = %v", tt.name, err)
+		}
+	}
+}
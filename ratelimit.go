@@ -48,6 +48,7 @@ func (r *RateLimiter) GetBucket(key string) *Bucket {
 	defer r.Unlock()
 
 	if bucket, ok := r.buckets[key]; ok {
+		bucket.touch(time.Now())
 		return bucket
 	}
 
@@ -55,6 +56,7 @@ func (r *RateLimiter) GetBucket(key string) *Bucket {
 		Remaining: 1,
 		Key:       key,
 		global:    r.global,
+		lastUsed:  time.Now().UnixNano(),
 	}
 
 	// Check if there is a custom ratelimit set for this bucket ID.
@@ -69,6 +71,27 @@ func (r *RateLimiter) GetBucket(key string) *Bucket {
 	return b
 }
 
+// Cleanup removes all buckets which have not been used for at least maxIdle
+// and whose ratelimit has reset, and returns the number of removed buckets.
+// Buckets with a custom ratelimit are never removed.
+// Long running bots touching many distinct endpoints should call this
+// periodically, as buckets are otherwise kept forever.
+// maxIdle should be well above the time a request can take.
+func (r *RateLimiter) Cleanup(maxIdle time.Duration) (removed int) {
+	r.Lock()
+	defer r.Unlock()
+
+	idleSince := time.Now().Add(-maxIdle).UnixNano()
+	for key, b := range r.buckets {
+		if b.customRateLimit == nil && atomic.LoadInt64(&b.lastUsed) < idleSince {
+			delete(r.buckets, key)
+			removed++
+		}
+	}
+
+	return
+}
+
 // GetWaitTime returns the duration you should wait for a Bucket
 func (r *RateLimiter) GetWaitTime(b *Bucket, minRemaining int) time.Duration {
 	// If we ran out of calls and the reset time is still ahead of us
@@ -93,9 +116,19 @@ func (r *RateLimiter) LockBucket(bucketID string) *Bucket {
 
 // LockBucketObject Locks an already resolved bucket until a request can be made
 func (r *RateLimiter) LockBucketObject(b *Bucket) *Bucket {
+	return r.lockBucketObject(b, nil)
+}
+
+// lockBucketObject locks an already resolved bucket until a request can be made.
+// If it has to wait for the bucket to reset, onWait is called with the wait
+// duration before sleeping.
+func (r *RateLimiter) lockBucketObject(b *Bucket, onWait func(b *Bucket, wait time.Duration)) *Bucket {
 	b.Lock()
 
 	if wait := r.GetWaitTime(b, 1); wait > 0 {
+		if onWait != nil {
+			onWait(b, wait)
+		}
 		time.Sleep(wait)
 	}
 
@@ -105,6 +138,11 @@ func (r *RateLimiter) LockBucketObject(b *Bucket) *Bucket {
 
 // Bucket represents a ratelimit bucket, each bucket gets ratelimited individually (-global ratelimits)
 type Bucket struct {
+	// lastUsed is the time in unix nanoseconds the bucket was last used or
+	// will reset at, whichever is later. It must be accessed atomically and
+	// is kept first for 64-bit alignment.
+	lastUsed int64
+
 	sync.Mutex
 	Key       string
 	Remaining int
@@ -121,6 +159,12 @@ type Bucket struct {
 // and locks up the whole thing in case if there's a global ratelimit.
 func (b *Bucket) Release(headers http.Header) error {
 	defer b.Unlock()
+	defer func() {
+		b.touch(time.Now())
+		if !b.reset.IsZero() {
+			b.touch(b.reset)
+		}
+	}()
 
 	// Check if the bucket uses a custom ratelimiter
 	if rl := b.customRateLimit; rl != nil {
@@ -195,3 +239,76 @@ func (b *Bucket) Release(headers http.Header) error {
 
 	return nil
 }
+
+// touch marks the bucket as used until t, unless it already is until later.
+func (b *Bucket) touch(t time.Time) {
+	nano := t.UnixNano()
+	for {
+		last := atomic.LoadInt64(&b.lastUsed)
+		if last >= nano || atomic.CompareAndSwapInt64(&b.lastUsed, last, nano) {
+			return
+		}
+	}
+}
+
+// Discord allows 120 gateway commands per 60 seconds on each connection and
+// closes the connection with 4008 when that is exceeded. Some headroom is
+// kept for heartbeats and identify/resume, which are not paced.
+const (
+	gatewayCommandLimit  = 110
+	gatewayCommandWindow = 60 * time.Second
+)
+
+// gatewayRateLimiter paces commands sent over the gateway websocket.
+// The zero value uses gatewayCommandLimit and gatewayCommandWindow.
+type gatewayRateLimiter struct {
+	sync.Mutex
+	limit  int
+	window time.Duration
+
+	sent  int
+	reset time.Time
+}
+
+// wait blocks until a gateway command may be sent and counts it.
+// Once a window is full, the command is counted in the next window and
+// wait sleeps until that window starts, without holding the lock.
+func (g *gatewayRateLimiter) wait() {
+	g.Lock()
+
+	limit, window := g.limit, g.window
+	if limit <= 0 {
+		limit = gatewayCommandLimit
+	}
+	if window <= 0 {
+		window = gatewayCommandWindow
+	}
+
+	now := time.Now()
+	if !now.Before(g.reset) {
+		g.sent = 0
+		g.reset = now.Add(window)
+	}
+	if g.sent >= limit {
+		g.sent = 0
+		g.reset = g.reset.Add(window)
+	}
+	g.sent++
+	start := g.reset.Add(-window)
+
+	g.Unlock()
+
+	if d := start.Sub(now); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// clear forgets the commands counted so far, as each gateway connection has
+// its own limit.
+func (g *gatewayRateLimiter) clear() {
+	g.Lock()
+	defer g.Unlock()
+
+	g.sent = 0
+	g.reset = time.Time{}
+}
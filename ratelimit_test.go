@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -111,3 +113,68 @@ func sendBenchReq(endpoint string, rl *RateLimiter) {
 
 	bucket.Release(headers)
 }
+
+func TestRatelimitCleanup(t *testing.T) {
+	rl := NewRatelimiter()
+
+	idle := rl.LockBucket("/channels/1/messages")
+	idle.Release(nil)
+	atomic.StoreInt64(&idle.lastUsed, time.Now().Add(-time.Hour).UnixNano())
+
+	resetting := rl.LockBucket("/channels/2/messages")
+	headers := http.Header{}
+	headers.Set("X-RateLimit-Remaining", "0")
+	headers.Set("X-RateLimit-Reset-After", "3600")
+	resetting.Release(headers)
+
+	custom := rl.LockBucket("/channels/3/messages//reactions//")
+	custom.Release(nil)
+	atomic.StoreInt64(&custom.lastUsed, time.Now().Add(-time.Hour).UnixNano())
+
+	rl.LockBucket("/channels/4/messages").Release(nil)
+
+	if removed := rl.Cleanup(time.Minute); removed != 1 {
+		t.Errorf("Cleanup removed %d buckets, want 1", removed)
+	}
+	if _, ok := rl.buckets[idle.Key]; ok {
+		t.Error("idle bucket was not removed")
+	}
+	if len(rl.buckets) != 3 {
+		t.Errorf("%d buckets left, want 3", len(rl.buckets))
+	}
+}
+
+// This test takes ~200 milliseconds to run
+func TestGatewayRateLimiter(t *testing.T) {
+	g := &gatewayRateLimiter{limit: 2, window: 200 * time.Millisecond}
+
+	start := time.Now()
+	g.wait()
+	g.wait()
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("commands within the limit were delayed by %v", elapsed)
+	}
+
+	// Commands waiting for the next window do not block others from
+	// being counted meanwhile.
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.wait()
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > 350*time.Millisecond {
+		t.Errorf("commands over the limit were delayed until %v, want one window", elapsed)
+	}
+
+	g.clear()
+	start = time.Now()
+	g.wait()
+	g.wait()
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("commands after clear were delayed by %v", elapsed)
+	}
+}
@@ -39,8 +39,19 @@ var (
 	ErrGuildNoIcon             = errors.New("guild does not have an icon set")
 	ErrGuildNoSplash           = errors.New("guild does not have a splash set")
 	ErrUnauthorized            = errors.New("HTTP request was unauthorized. This could be because the provided token was not a bot token. Please add \"Bot \" to the start of your token. https://discord.com/developers/docs/reference#authentication-example-bot-token-authorization-header")
+	ErrMissingAccess           = errors.New("missing access")
+	ErrMissingPermissions      = errors.New("missing permissions")
+	ErrGuildMemberExists       = errors.New("user is already a member of the guild")
+	ErrInvalidUserID           = errors.New("a user ID is required, use MessageReactionRemoveMe for the current user")
 )
 
+// restErrorCodes maps Discord JSON error codes to the error constants
+// a RESTError carrying that code matches with errors.Is.
+var restErrorCodes = map[int]error{
+	ErrCodeMissingAccess:      ErrMissingAccess,
+	ErrCodeMissingPermissions: ErrMissingPermissions,
+}
+
 var (
 	// Marshal defines function used to encode JSON payloads
 	Marshal func(v interface{}) ([]byte, error) = json.Marshal
@@ -82,6 +93,17 @@ func (r RESTError) Error() string {
 	return "HTTP " + r.Response.Status + ", " + string(r.ResponseBody)
 }
 
+// Is reports whether the Discord error code of the response matches target,
+// e.g. errors.Is(err, ErrMissingPermissions).
+func (r RESTError) Is(target error) bool {
+	if r.Message == nil {
+		return false
+	}
+
+	err, ok := restErrorCodes[r.Message.Code]
+	return ok && err == target
+}
+
 // RateLimitError is returned when a request exceeds a rate limit
 // and ShouldRetryOnRateLimit is false. The request may be manually
 // retried after waiting the duration specified by RetryAfter.
@@ -181,14 +203,33 @@ func (s *Session) RequestWithBucketID(method, urlStr string, data interface{}, b
 	return s.request(method, urlStr, "application/json", body, bucketID, 0, options...)
 }
 
+// RequestRaw makes a (GET/POST/...) Requests to Discord REST API with a raw body
+// of the given content type. It respects ratelimits and retries like any other
+// request, so it can be used for endpoints that have no dedicated method yet.
+// If bucketID is empty, the url without its query string is used as bucket.
+func (s *Session) RequestRaw(method, urlStr, contentType string, b []byte, bucketID string, options ...RequestOption) (response []byte, err error) {
+	return s.request(method, urlStr, contentType, b, bucketID, 0, options...)
+}
+
 // request makes a (GET/POST/...) Requests to Discord REST API.
-// Sequence is the sequence number, if it fails with a 502 it will
+// Sequence is the sequence number, if it fails with a 502 or 429 it will
 // retry with sequence+1 until it either succeeds or sequence >= session.MaxRestRetries
 func (s *Session) request(method, urlStr, contentType string, b []byte, bucketID string, sequence int, options ...RequestOption) (response []byte, err error) {
 	if bucketID == "" {
 		bucketID = strings.SplitN(urlStr, "?", 2)[0]
 	}
-	return s.RequestWithLockedBucket(method, urlStr, contentType, b, s.Ratelimiter.LockBucket(bucketID), sequence, options...)
+	return s.RequestWithLockedBucket(method, urlStr, contentType, b, s.lockBucket(urlStr, s.Ratelimiter.GetBucket(bucketID)), sequence, options...)
+}
+
+// lockBucket locks the bucket until a request can be made, emitting a
+// RateLimit event if it has to wait for the bucket to reset first.
+// The event is emitted asynchronously, as the bucket is locked meanwhile and
+// a handler making a request on the same bucket would deadlock otherwise.
+func (s *Session) lockBucket(urlStr string, bucket *Bucket) *Bucket {
+	return s.Ratelimiter.lockBucketObject(bucket, func(b *Bucket, wait time.Duration) {
+		s.log(LogInformational, "Rate Limiting %s, waiting %v for bucket %s to reset", urlStr, wait, b.Key)
+		go s.handleEvent(rateLimitEventType, &RateLimit{TooManyRequests: &TooManyRequests{Bucket: b.Key, RetryAfter: wait}, URL: urlStr})
+	})
 }
 
 // RequestWithLockedBucket makes a request using a bucket that's already been locked
@@ -207,7 +248,7 @@ func (s *Session) RequestWithLockedBucket(method, urlStr, contentType string, b
 	// Not used on initial login..
 	// TODO: Verify if a login, otherwise complain about no-token
 	if s.Token != "" {
-		req.Header.Set("authorization", s.Token)
+		req.Header.Set("authorization", s.authorization(s.Token))
 	}
 
 	// Discord's API returns a 400 Bad Request is Content-Type is set, but the
@@ -231,8 +272,17 @@ func (s *Session) RequestWithLockedBucket(method, urlStr, contentType string, b
 		}
 	}
 
+	// The slot is only taken once the bucket allows the request, so that
+	// requests waiting for their bucket to reset do not hold one.
+	release, err := s.acquireRequestSlot(req.Context())
+	if err != nil {
+		bucket.Release(nil)
+		return
+	}
+
 	resp, err := cfg.Client.Do(req)
 	if err != nil {
+		release()
 		bucket.Release(nil)
 		return
 	}
@@ -244,11 +294,11 @@ func (s *Session) RequestWithLockedBucket(method, urlStr, contentType string, b
 	}()
 
 	err = bucket.Release(resp.Header)
-	if err != nil {
-		return
+	if err == nil {
+		response, err = ioutil.ReadAll(resp.Body)
 	}
-
-	response, err = ioutil.ReadAll(resp.Body)
+	// Released before any retry below, which takes a slot of its own.
+	release()
 	if err != nil {
 		return
 	}
@@ -271,7 +321,7 @@ func (s *Session) RequestWithLockedBucket(method, urlStr, contentType string, b
 		if sequence < cfg.MaxRestRetries {
 
 			s.log(LogInformational, "%s Failed (%s), Retrying...", urlStr, resp.Status)
-			response, err = s.RequestWithLockedBucket(method, urlStr, contentType, b, s.Ratelimiter.LockBucketObject(bucket), sequence+1, options...)
+			response, err = s.RequestWithLockedBucket(method, urlStr, contentType, b, s.lockBucket(urlStr, bucket), sequence+1, options...)
 		} else {
 			err = fmt.Errorf("Exceeded Max retries HTTP %s, %s", resp.Status, response)
 		}
@@ -280,23 +330,46 @@ func (s *Session) RequestWithLockedBucket(method, urlStr, contentType string, b
 		err = Unmarshal(response, &rl)
 		if err != nil {
 			s.log(LogError, "rate limit unmarshal error, %s", err)
+		}
+
+		// The retry_after of the body is more precise than the Retry-After
+		// header, which only has whole seconds, so it is just a fallback.
+		if rl.RetryAfter == 0 {
+			if after, err2 := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err2 == nil {
+				rl.RetryAfter = time.Duration(after * float64(time.Second))
+				err = nil
+			}
+		}
+		if err != nil {
 			return
 		}
 
-		if cfg.ShouldRetryOnRateLimit {
+		if rl.Bucket == "" {
+			rl.Bucket = bucket.Key
+		}
+		if resp.Header.Get("X-RateLimit-Global") != "" || resp.Header.Get("X-RateLimit-Scope") == "global" {
+			rl.Global = true
+		}
+
+		rateLimit := &RateLimit{TooManyRequests: &rl, URL: urlStr}
+		s.handleEvent(rateLimitEventType, rateLimit)
+
+		if cfg.ShouldRetryOnRateLimit && sequence < cfg.MaxRestRetries {
 			s.log(LogInformational, "Rate Limiting %s, retry in %v", urlStr, rl.RetryAfter)
-			s.handleEvent(rateLimitEventType, &RateLimit{TooManyRequests: &rl, URL: urlStr})
 
 			time.Sleep(rl.RetryAfter)
 			// we can make the above smarter
 			// this method can cause longer delays than required
 
-			response, err = s.RequestWithLockedBucket(method, urlStr, contentType, b, s.Ratelimiter.LockBucketObject(bucket), sequence, options...)
+			response, err = s.RequestWithLockedBucket(method, urlStr, contentType, b, s.lockBucket(urlStr, bucket), sequence+1, options...)
 		} else {
-			err = &RateLimitError{&RateLimit{TooManyRequests: &rl, URL: urlStr}}
+			if cfg.ShouldRetryOnRateLimit {
+				s.log(LogWarning, "Rate Limiting %s, giving up after %d retries", urlStr, sequence)
+			}
+			err = &RateLimitError{rateLimit}
 		}
 	case http.StatusUnauthorized:
-		if strings.Index(s.Token, "Bot ") != 0 {
+		if !strings.HasPrefix(s.authorization(s.Token), "Bot ") {
 			s.log(LogInformational, ErrUnauthorized.Error())
 			err = ErrUnauthorized
 		}
@@ -308,6 +381,48 @@ func (s *Session) RequestWithLockedBucket(method, urlStr, contentType string, b
 	return
 }
 
+// acquireRequestSlot waits until fewer than MaxConcurrentRequests requests
+// are in flight and returns a function releasing the taken slot. It returns
+// the error of ctx if it is done first.
+func (s *Session) acquireRequestSlot(ctx context.Context) (release func(), err error) {
+	sem := s.requestSemaphore()
+	if sem == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// requestSemaphore returns the semaphore limiting the REST requests in
+// flight to MaxConcurrentRequests, or nil when they are not limited.
+func (s *Session) requestSemaphore() chan struct{} {
+	s.requestSemMu.Lock()
+	defer s.requestSemMu.Unlock()
+
+	if s.MaxConcurrentRequests <= 0 {
+		return nil
+	}
+	if cap(s.requestSem) != s.MaxConcurrentRequests {
+		s.requestSem = make(chan struct{}, s.MaxConcurrentRequests)
+	}
+	return s.requestSem
+}
+
+// authorization returns the token as it is sent to Discord, with the "Bot "
+// prefix added if ShouldPrefixToken is set and it has no known prefix.
+func (s *Session) authorization(token string) string {
+	if !s.ShouldPrefixToken || token == "" || strings.HasPrefix(token, "Bot ") || strings.HasPrefix(token, "Bearer ") {
+		return token
+	}
+
+	return "Bot " + token
+}
+
 func unmarshal(data []byte, v interface{}) error {
 	err := Unmarshal(data, v)
 	if err != nil {
@@ -334,6 +449,34 @@ func (s *Session) User(userID string, options ...RequestOption) (st *User, err e
 	return
 }
 
+// CurrentUser returns the session user. With StateEnabled, it is taken from
+// the State when it has been received with the Ready event, and is otherwise
+// fetched and then cached in the State, so it is only requested once.
+func (s *Session) CurrentUser(options ...RequestOption) (st *User, err error) {
+	if s.StateEnabled && s.State != nil {
+		s.State.RLock()
+		st = s.State.User
+		s.State.RUnlock()
+		if st != nil {
+			return
+		}
+	}
+
+	st, err = s.User("@me", options...)
+	if err != nil {
+		return
+	}
+
+	if s.StateEnabled && s.State != nil {
+		s.State.Lock()
+		if s.State.User == nil {
+			s.State.User = st
+		}
+		s.State.Unlock()
+	}
+	return
+}
+
 // UserAvatar is deprecated. Please use UserAvatarDecode
 // userID    : A user ID or "@me" which is a shortcut of current user ID
 func (s *Session) UserAvatar(userID string, options ...RequestOption) (img image.Image, err error) {
@@ -380,6 +523,19 @@ func (s *Session) UserUpdate(username, avatar, banner string, options ...Request
 	return
 }
 
+// UserUpdateComplex updates the settings of the session user which are set
+// in params, leaving the others unchanged.
+// params : The settings to change.
+func (s *Session) UserUpdateComplex(params *UserUpdateParams, options ...RequestOption) (st *User, err error) {
+	body, err := s.RequestWithBucketID("PATCH", EndpointUser("@me"), params, EndpointUsers, options...)
+	if err != nil {
+		return
+	}
+
+	err = unmarshal(body, &st)
+	return
+}
+
 // UserConnections returns the user's connections
 func (s *Session) UserConnections(options ...RequestOption) (conn []*UserConnection, err error) {
 	response, err := s.RequestWithBucketID("GET", EndpointUserConnections("@me"), nil, EndpointUserConnections("@me"), options...)
@@ -724,6 +880,34 @@ func (s *Session) GuildBans(guildID string, limit int, beforeID, afterID string,
 	return
 }
 
+// GuildBansEach pages through GuildBans and calls fn with each ban in the
+// given guild, stopping once fn returns false. Only one page of bans is held
+// in memory at a time.
+// guildID  : The ID of a Guild.
+// fn       : Called with each ban, returns whether to continue.
+func (s *Session) GuildBansEach(guildID string, fn func(*GuildBan) bool, options ...RequestOption) error {
+	const limit = 1000
+
+	after := ""
+	for {
+		page, err := s.GuildBans(guildID, limit, "", after, options...)
+		if err != nil {
+			return err
+		}
+
+		for _, b := range page {
+			if !fn(b) {
+				return nil
+			}
+		}
+
+		if len(page) < limit || page[len(page)-1].User == nil {
+			return nil
+		}
+		after = page[len(page)-1].User.ID
+	}
+}
+
 // GuildBanCreate bans the given user from the given guild.
 // guildID   : The ID of a Guild.
 // userID    : The ID of a User
@@ -810,6 +994,33 @@ func (s *Session) GuildMembers(guildID string, after string, limit int, options
 	return
 }
 
+// GuildMembersAll returns every member of a guild, paging through
+// GuildMembers until all members have been fetched. Unlike RequestGuildMembers
+// this does not require the privileged GUILD_MEMBERS gateway intent.
+// guildID  : The ID of a Guild.
+func (s *Session) GuildMembersAll(guildID string, options ...RequestOption) (st []*Member, err error) {
+	const limit = 1000
+
+	after := ""
+	for {
+		var page []*Member
+		page, err = s.GuildMembers(guildID, after, limit, options...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range page {
+			m.GuildID = guildID
+		}
+		st = append(st, page...)
+
+		if len(page) < limit || page[len(page)-1].User == nil {
+			return
+		}
+		after = page[len(page)-1].User.ID
+	}
+}
+
 // GuildMembersSearch returns a list of guild member objects whose username or nickname starts with a provided string
 // guildID  : The ID of a Guild
 // query    : Query string to match username(s) and nickname(s) against
@@ -833,7 +1044,8 @@ func (s *Session) GuildMembersSearch(guildID, query string, limit int, options .
 	return
 }
 
-// GuildMember returns a member of a guild.
+// GuildMember returns a member of a guild, always fetched from Discord.
+// Use GuildMemberCached to check the state cache first.
 // guildID   : The ID of a Guild.
 // userID    : The ID of a User
 func (s *Session) GuildMember(guildID, userID string, options ...RequestOption) (st *Member, err error) {
@@ -849,6 +1061,30 @@ func (s *Session) GuildMember(guildID, userID string, options ...RequestOption)
 	return
 }
 
+// GuildMemberCached returns a member of a guild from the state cache,
+// falling back to GuildMember on a miss and caching the fetched member.
+// Use GuildMember to always fetch the member from Discord.
+// guildID   : The ID of a Guild.
+// userID    : The ID of a User
+func (s *Session) GuildMemberCached(guildID, userID string, options ...RequestOption) (st *Member, err error) {
+	if s.StateEnabled {
+		st, err = s.State.Member(guildID, userID)
+		if err == nil {
+			return
+		}
+	}
+
+	st, err = s.GuildMember(guildID, userID, options...)
+	if err != nil {
+		return
+	}
+
+	if s.StateEnabled && s.State != nil && s.State.TrackMembers {
+		s.State.MemberAdd(st)
+	}
+	return
+}
+
 // GuildMemberAdd force joins a user to the guild.
 // guildID       : The ID of a Guild.
 // userID        : The ID of a User.
@@ -863,6 +1099,33 @@ func (s *Session) GuildMemberAdd(guildID, userID string, data *GuildMemberAddPar
 	return err
 }
 
+// GuildMemberAddComplex force joins a user to the guild using their OAuth2
+// access token (with the guilds.join scope) and returns the new member.
+// If the user already is a member of the guild, nothing is changed and
+// ErrGuildMemberExists is returned.
+// guildID       : The ID of a Guild.
+// userID        : The ID of a User.
+// data          : Parameters of the user to add.
+func (s *Session) GuildMemberAddComplex(guildID, userID string, data *GuildMemberAddParams, options ...RequestOption) (st *Member, err error) {
+	body, err := s.RequestWithBucketID("PUT", EndpointGuildMember(guildID, userID), data, EndpointGuildMember(guildID, ""), options...)
+	if err != nil {
+		return
+	}
+
+	// Discord responds with 204 No Content if the user already is a member.
+	if len(body) == 0 {
+		return nil, ErrGuildMemberExists
+	}
+
+	err = unmarshal(body, &st)
+	if err != nil {
+		return
+	}
+
+	st.GuildID = guildID
+	return
+}
+
 // GuildMemberDelete removes the given user from the given guild.
 // guildID   : The ID of a Guild.
 // userID    : The ID of a User
@@ -1676,6 +1939,18 @@ func (s *Session) ChannelTyping(channelID string, options ...RequestOption) (err
 	return
 }
 
+// ChannelVoiceStatusEdit sets the status of a voice channel.
+// channelID : The ID of a voice Channel.
+// status    : The new status of the channel, an empty string clears it.
+func (s *Session) ChannelVoiceStatusEdit(channelID, status string, options ...RequestOption) (err error) {
+	data := struct {
+		Status string `json:"status"`
+	}{status}
+
+	_, err = s.RequestWithBucketID("PUT", EndpointChannelVoiceStatus(channelID), data, EndpointChannelVoiceStatus(channelID), options...)
+	return
+}
+
 // ChannelMessages returns an array of Message structures for messages within
 // a given channel.
 // channelID : The ID of a Channel.
@@ -1738,10 +2013,39 @@ func (s *Session) ChannelMessageSend(channelID string, content string, options .
 
 var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
 
+// isConnectionError returns whether err happened while sending a request or
+// reading its response, rather than being returned by Discord.
+func isConnectionError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled)
+}
+
+// allowedMentions returns the allowed mentions to send with a new message,
+// which exclude @everyone and @here when SuppressEveryone is set and the
+// caller did not specify any.
+func (s *Session) allowedMentions(am *MessageAllowedMentions) *MessageAllowedMentions {
+	if am != nil || !s.SuppressEveryone {
+		return am
+	}
+
+	return &MessageAllowedMentions{
+		Parse:       []AllowedMentionType{AllowedMentionTypeUsers, AllowedMentionTypeRoles},
+		RepliedUser: true,
+	}
+}
+
 // ChannelMessageSendComplex sends a message to the given channel.
 // channelID : The ID of a Channel.
 // data      : The message struct to send.
 func (s *Session) ChannelMessageSendComplex(channelID string, data *MessageSend, options ...RequestOption) (st *Message, err error) {
+	if s.BeforeMessageSend != nil {
+		// The hook is given a copy, so that a MessageSend sent again is not
+		// modified twice.
+		hooked := *data
+		s.BeforeMessageSend(channelID, &hooked)
+		data = &hooked
+	}
+
 	// TODO: Remove this when compatibility is not required.
 	if data.Embed != nil {
 		if data.Embeds == nil {
@@ -1757,6 +2061,13 @@ func (s *Session) ChannelMessageSendComplex(channelID string, data *MessageSend,
 			embed.Type = "rich"
 		}
 	}
+	// The allowed mentions are set on a copy, so that they do not look like
+	// the caller's choice when the MessageSend is sent again.
+	if am := s.allowedMentions(data.AllowedMentions); am != data.AllowedMentions {
+		withMentions := *data
+		withMentions.AllowedMentions = am
+		data = &withMentions
+	}
 	endpoint := EndpointChannelMessages(channelID)
 
 	// TODO: Remove this when compatibility is not required.
@@ -1770,28 +2081,55 @@ func (s *Session) ChannelMessageSendComplex(channelID string, data *MessageSend,
 		}
 	}
 
-	if data.StickerIDs != nil {
-		if len(data.StickerIDs) > 3 {
-			err = fmt.Errorf("cannot send more than 3 stickers")
+	if err = data.Validate(); err != nil {
+		return
+	}
+	if s.MaxUploadSize > 0 {
+		if err = data.ValidateFileSizes(s.MaxUploadSize); err != nil {
 			return
 		}
 	}
 
-	var response []byte
+	if data.Nonce == "" && s.TrackSentNonces {
+		// The nonce is set on a copy, so that a MessageSend reused for
+		// several messages is not deduplicated into the first of them.
+		withNonce := *data
+		withNonce.Nonce = newNonce()
+		withNonce.EnforceNonce = true
+		data = &withNonce
+	}
+
+	var contentType string
+	var body []byte
 	if len(files) > 0 {
-		contentType, body, encodeErr := MultipartBodyWithJSON(data, files)
-		if encodeErr != nil {
-			return st, encodeErr
+		contentType, body, err = MultipartBodyWithJSON(data, files)
+		if err != nil {
+			return
 		}
-		response, err = s.request("POST", endpoint, contentType, body, endpoint, 0, options...)
-	} else {
-		response, err = s.RequestWithBucketID("POST", endpoint, data, endpoint, options...)
+	}
+
+	send := func() ([]byte, error) {
+		if body != nil {
+			return s.request("POST", endpoint, contentType, body, endpoint, 0, options...)
+		}
+		return s.RequestWithBucketID("POST", endpoint, data, endpoint, options...)
+	}
+
+	response, err := send()
+	// Discord deduplicates messages by their enforced nonce, so a message
+	// which may or may not have been delivered can safely be sent again.
+	if data.Nonce != "" && data.EnforceNonce && isConnectionError(err) {
+		s.log(LogInformational, "retrying message send with nonce %s, %s", data.Nonce, err)
+		response, err = send()
 	}
 	if err != nil {
 		return
 	}
 
 	err = unmarshal(response, &st)
+	if err == nil && st.Nonce == "" {
+		st.Nonce = data.Nonce
+	}
 	return
 }
 
@@ -1977,7 +2315,8 @@ func (s *Session) ChannelMessageUnpin(channelID, messageID string, options ...Re
 }
 
 // ChannelMessagesPinned returns an array of Message structures for pinned messages
-// within a given channel
+// within a given channel. When the state is enabled the result is also cached,
+// see State.ChannelPins.
 // channelID : The ID of a Channel.
 func (s *Session) ChannelMessagesPinned(channelID string, options ...RequestOption) (st []*Message, err error) {
 
@@ -1988,6 +2327,9 @@ func (s *Session) ChannelMessagesPinned(channelID string, options ...RequestOpti
 	}
 
 	err = unmarshal(body, &st)
+	if err == nil && s.StateEnabled && s.State != nil && s.State.TrackChannels {
+		s.State.ChannelPinsSet(channelID, st)
+	}
 	return
 }
 
@@ -2425,6 +2767,12 @@ func (s *Session) webhookExecute(webhookID, token string, wait bool, threadID st
 		uri += "?" + v.Encode()
 	}
 
+	if am := s.allowedMentions(data.AllowedMentions); am != data.AllowedMentions {
+		withMentions := *data
+		withMentions.AllowedMentions = am
+		data = &withMentions
+	}
+
 	var response []byte
 	if len(data.Files) > 0 {
 		contentType, body, encodeErr := MultipartBodyWithJSON(data, data.Files)
@@ -2532,6 +2880,55 @@ func (s *Session) MessageReactionAdd(channelID, messageID, emojiID string, optio
 	return err
 }
 
+// MessageReactionsAddBulk adds the same emoji reaction to many messages,
+// like MessageReactionsAddOrdered does for many reactions to one message.
+// channelID  : The channel ID.
+// messageIDs : The IDs of the messages to react to.
+// emojiID    : Either the unicode emoji for the reaction, or a guild emoji identifier in name:id format (e.g. "hello:1234567654321")
+func (s *Session) MessageReactionsAddBulk(channelID string, messageIDs []string, emojiID string, options ...RequestOption) error {
+	reactions := make([]messageReaction, len(messageIDs))
+	for i, messageID := range messageIDs {
+		reactions[i] = messageReaction{messageID, emojiID}
+	}
+	return s.messageReactionsAdd(channelID, reactions, options)
+}
+
+// MessageReactionsAddOrdered adds several emoji reactions to a message one
+// after another, so they are shown in the given order. Each reaction is only
+// added once the previous one succeeded, and requests are retried on rate
+// limits, regardless of ShouldRetryOnRateLimit.
+// It stops at the first reaction which fails.
+// channelID : The channel ID.
+// messageID : The message ID.
+// emojiIDs  : The reactions, each either a unicode emoji or a guild emoji identifier in name:id format.
+func (s *Session) MessageReactionsAddOrdered(channelID, messageID string, emojiIDs []string, options ...RequestOption) error {
+	reactions := make([]messageReaction, len(emojiIDs))
+	for i, emojiID := range emojiIDs {
+		reactions[i] = messageReaction{messageID, emojiID}
+	}
+	return s.messageReactionsAdd(channelID, reactions, options)
+}
+
+// messageReaction is a reaction added by messageReactionsAdd.
+type messageReaction struct {
+	messageID string
+	emojiID   string
+}
+
+// messageReactionsAdd adds the reactions one after another, retrying on rate
+// limits and stopping at the first which fails.
+func (s *Session) messageReactionsAdd(channelID string, reactions []messageReaction, options []RequestOption) error {
+	options = append([]RequestOption{WithRetryOnRatelimit(true)}, options...)
+
+	for _, r := range reactions {
+		if err := s.MessageReactionAdd(channelID, r.messageID, r.emojiID, options...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // MessageReactionRemove deletes an emoji reaction to a message.
 // channelID : The channel ID.
 // messageID : The message ID.
@@ -2546,6 +2943,26 @@ func (s *Session) MessageReactionRemove(channelID, messageID, emojiID, userID st
 	return err
 }
 
+// MessageReactionRemoveMe deletes the current user's emoji reaction to a message.
+// channelID : The channel ID.
+// messageID : The message ID.
+// emojiID   : Either the unicode emoji for the reaction, or a guild emoji identifier.
+func (s *Session) MessageReactionRemoveMe(channelID, messageID, emojiID string, options ...RequestOption) error {
+	return s.MessageReactionRemove(channelID, messageID, emojiID, "@me", options...)
+}
+
+// MessageReactionRemoveUser deletes another user's emoji reaction to a message.
+// channelID : The channel ID.
+// messageID : The message ID.
+// emojiID   : Either the unicode emoji for the reaction, or a guild emoji identifier.
+// userID    : The ID of the user whose reaction is deleted.
+func (s *Session) MessageReactionRemoveUser(channelID, messageID, emojiID, userID string, options ...RequestOption) error {
+	if userID == "" || userID == "@me" {
+		return ErrInvalidUserID
+	}
+	return s.MessageReactionRemove(channelID, messageID, emojiID, userID, options...)
+}
+
 // MessageReactionsRemoveAll deletes all reactions from a message
 // channelID : The channel ID
 // messageID : The message ID.
@@ -2607,6 +3024,25 @@ func (s *Session) MessageReactions(channelID, messageID, emojiID string, limit i
 	return
 }
 
+// MessageReactionsCount fetches a message and returns the count of each of
+// its reactions, keyed by the emoji API name (see Emoji.APIName).
+// channelID : The channel ID.
+// messageID : The message ID.
+func (s *Session) MessageReactionsCount(channelID, messageID string, options ...RequestOption) (counts map[string]int, err error) {
+	m, err := s.ChannelMessage(channelID, messageID, options...)
+	if err != nil {
+		return
+	}
+
+	counts = make(map[string]int, len(m.Reactions))
+	for _, r := range m.Reactions {
+		if r.Emoji != nil {
+			counts[r.Emoji.APIName()] = r.Count
+		}
+	}
+	return
+}
+
 // ------------------------------------------------------------------------------------------------
 // Functions specific to threads
 // ------------------------------------------------------------------------------------------------
@@ -2688,6 +3124,11 @@ func (s *Session) ForumThreadStartComplex(channelID string, threadData *ThreadSt
 			embed.Type = "rich"
 		}
 	}
+	if am := s.allowedMentions(messageData.AllowedMentions); am != messageData.AllowedMentions {
+		withMentions := *messageData
+		withMentions.AllowedMentions = am
+		messageData = &withMentions
+	}
 
 	// TODO: Remove this when compatibility is not required.
 	files := messageData.Files
@@ -3136,6 +3577,16 @@ func (s *Session) ApplicationCommandPermissionsBatchEdit(appID, guildID string,
 func (s *Session) InteractionRespond(interaction *Interaction, resp *InteractionResponse, options ...RequestOption) error {
 	endpoint := EndpointInteractionResponse(interaction.ID, interaction.Token)
 
+	if resp.Type == InteractionResponseChannelMessageWithSource && resp.Data != nil {
+		if am := s.allowedMentions(resp.Data.AllowedMentions); am != resp.Data.AllowedMentions {
+			data := *resp.Data
+			data.AllowedMentions = am
+			withMentions := *resp
+			withMentions.Data = &data
+			resp = &withMentions
+		}
+	}
+
 	if resp.Data != nil && len(resp.Data.Files) > 0 {
 		contentType, body, err := MultipartBodyWithJSON(resp, resp.Data.Files)
 		if err != nil {
@@ -3150,6 +3601,22 @@ func (s *Session) InteractionRespond(interaction *Interaction, resp *Interaction
 	return err
 }
 
+// InteractionDefer acknowledges an interaction, so that its response can be
+// sent later with InteractionResponseEdit. Message component interactions are
+// deferred as a message update, others as a new message.
+// interaction : Interaction instance.
+// ephemeral   : Whether the response is only visible to the invoking user (not used for message components).
+func (s *Session) InteractionDefer(interaction *Interaction, ephemeral bool, options ...RequestOption) error {
+	resp := &InteractionResponse{Type: InteractionResponseDeferredChannelMessageWithSource}
+	if interaction.Type == InteractionMessageComponent {
+		resp.Type = InteractionResponseDeferredMessageUpdate
+	} else if ephemeral {
+		resp.Data = &InteractionResponseData{Flags: MessageFlagsEphemeral}
+	}
+
+	return s.InteractionRespond(interaction, resp, options...)
+}
+
 // InteractionResponse gets the response to an interaction.
 // interaction : Interaction instance.
 func (s *Session) InteractionResponse(interaction *Interaction, options ...RequestOption) (*Message, error) {
@@ -2,9 +2,16 @@ package discordgo
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 //////////////////////////////////////////////////////////////////////////////
@@ -275,3 +282,810 @@ type roundTripperFunc func(*http.Request) (*http.Response, error)
 func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req)
 }
+
+// newTestSession returns a session whose REST requests are answered by
+// handler instead of Discord.
+func newTestSession(t *testing.T, handler func(r *http.Request) (*http.Response, error)) *Session {
+	t.Helper()
+
+	session, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Client.Transport = roundTripperFunc(handler)
+	return session
+}
+
+// testResponse returns a response with the given status code and body.
+func testResponse(code int, body string) *http.Response {
+	return &http.Response{
+		Status:     strconv.Itoa(code) + " " + http.StatusText(code),
+		StatusCode: code,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// testRateLimitedResponse returns a 429 response asking to retry after retryAfter seconds.
+func testRateLimitedResponse(retryAfter string) *http.Response {
+	return testResponse(http.StatusTooManyRequests, `{"message": "You are being rate limited.", "retry_after": `+retryAfter+`}`)
+}
+
+// testUsersPage answers a request for a page of objects with a user, such as
+// members or bans, out of total objects with the user IDs 1 to total.
+func testUsersPage(r *http.Request, total int) *http.Response {
+	start := 0
+	if after := r.URL.Query().Get("after"); after != "" {
+		start, _ = strconv.Atoi(after)
+	}
+	var objects []string
+	for i := start + 1; i <= total && len(objects) < 1000; i++ {
+		objects = append(objects, `{"user": {"id": "`+strconv.Itoa(i)+`"}}`)
+	}
+	return testResponse(http.StatusOK, "["+strings.Join(objects, ",")+"]")
+}
+
+func TestRequestEndpoints(t *testing.T) {
+	avatar := "data:image/png;base64,AAAA"
+	tests := []struct {
+		name     string
+		call     func(s *Session) error
+		method   string
+		endpoint string
+		body     string
+	}{
+		{
+			"MessageReactionRemoveMe",
+			func(s *Session) error { return s.MessageReactionRemoveMe("channel", "message", "emoji:1234") },
+			"DELETE", EndpointMessageReaction("channel", "message", "emoji:1234", "@me"), "",
+		},
+		{
+			"MessageReactionRemoveUser",
+			func(s *Session) error { return s.MessageReactionRemoveUser("channel", "message", "emoji:1234", "user") },
+			"DELETE", EndpointMessageReaction("channel", "message", "emoji:1234", "user"), "",
+		},
+		{
+			"ChannelVoiceStatusEdit",
+			func(s *Session) error { return s.ChannelVoiceStatusEdit("channel", "Now playing") },
+			"PUT", EndpointChannelVoiceStatus("channel"), `{"status":"Now playing"}`,
+		},
+		{
+			"UserUpdateComplex",
+			func(s *Session) error {
+				_, err := s.UserUpdateComplex(&UserUpdateParams{Avatar: &avatar})
+				return err
+			},
+			"PATCH", EndpointUser("@me"), `{"avatar":"data:image/png;base64,AAAA"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method, endpoint, body string
+			session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(r.Body)
+				method, endpoint, body = r.Method, r.URL.String(), string(b)
+				return testResponse(http.StatusOK, `{}`), nil
+			})
+
+			if err := tt.call(session); err != nil {
+				t.Fatalf("returned error: %+v", err)
+			}
+			if method != tt.method || endpoint != tt.endpoint || body != tt.body {
+				t.Errorf("sent %s %s %s, want %s %s %s", method, endpoint, body, tt.method, tt.endpoint, tt.body)
+			}
+		})
+	}
+}
+
+func TestRESTErrorIs(t *testing.T) {
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		return testResponse(http.StatusForbidden, `{"message": "Missing Permissions", "code": 50013}`), nil
+	})
+
+	_, err := session.ChannelMessageSend("channel", "content")
+	if !errors.Is(err, ErrMissingPermissions) {
+		t.Errorf("expected ErrMissingPermissions, got %v", err)
+	}
+	if errors.Is(err, ErrMissingAccess) {
+		t.Error("did not expect ErrMissingAccess")
+	}
+
+	var restErr *RESTError
+	if !errors.As(err, &restErr) || restErr.Message.Code != ErrCodeMissingPermissions {
+		t.Errorf("expected *RESTError with code %d, got %v", ErrCodeMissingPermissions, err)
+	}
+}
+
+func TestRequestRaw(t *testing.T) {
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		if ct := r.Header.Get("Content-Type"); ct != "text/plain" {
+			t.Errorf("unexpected Content-Type %q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		return testResponse(http.StatusOK, string(body)), nil
+	})
+
+	response, err := session.RequestRaw("POST", EndpointAPI+"custom?x=1", "text/plain", []byte("payload"), "")
+	if err != nil {
+		t.Fatalf("RequestRaw returned error: %+v", err)
+	}
+	if string(response) != "payload" {
+		t.Errorf("RequestRaw returned %q, want %q", response, "payload")
+	}
+	if _, ok := session.Ratelimiter.buckets[EndpointAPI+"custom"]; !ok {
+		t.Error("RequestRaw did not use the url without query string as bucket")
+	}
+}
+
+func TestRateLimitEventOnBucketWait(t *testing.T) {
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		resp := testResponse(http.StatusOK, `{}`)
+		resp.Header.Set("X-RateLimit-Remaining", "0")
+		resp.Header.Set("X-RateLimit-Reset-After", "0.2")
+		return resp, nil
+	})
+	session.SyncEvents = true
+
+	events := make(chan *RateLimit, 1)
+	var once sync.Once
+	session.AddHandler(func(s *Session, r *RateLimit) {
+		once.Do(func() {
+			// A request on the waiting bucket must not deadlock.
+			if _, err := s.Channel("channel"); err != nil {
+				t.Errorf("Channel in RateLimit handler returned error: %+v", err)
+			}
+			events <- r
+		})
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := session.Channel("channel"); err != nil {
+			t.Fatalf("Channel returned error: %+v", err)
+		}
+	}
+
+	select {
+	case event := <-events:
+		if event.Bucket != EndpointChannel("channel") || event.RetryAfter <= 0 {
+			t.Errorf("unexpected RateLimit event %+v", event.TooManyRequests)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("RateLimit event was not emitted")
+	}
+}
+
+func TestRateLimitEventOn429(t *testing.T) {
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		resp := testRateLimitedResponse("1.5")
+		resp.Header.Set("X-RateLimit-Global", "true")
+		return resp, nil
+	})
+	session.SyncEvents = true
+	session.ShouldRetryOnRateLimit = false
+
+	var event *RateLimit
+	session.AddHandler(func(s *Session, r *RateLimit) {
+		event = r
+	})
+
+	_, err := session.Channel("channel")
+
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected *RateLimitError, got %v", err)
+	}
+	if event == nil {
+		t.Fatal("RateLimit event was not emitted")
+	}
+	if !event.Global || event.RetryAfter != 1500*time.Millisecond || event.Bucket != EndpointChannel("channel") {
+		t.Errorf("unexpected RateLimit event %+v", event.TooManyRequests)
+	}
+}
+
+func TestRateLimitRetriesBounded(t *testing.T) {
+	requests := 0
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		requests++
+		return testRateLimitedResponse("0.001"), nil
+	})
+	session.ShouldRetryOnRateLimit = true
+	session.MaxRestRetries = 2
+
+	_, err := session.Channel("channel")
+
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected *RateLimitError, got %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("made %d requests, want 3", requests)
+	}
+}
+
+func TestTooManyRequestsRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		body       string
+		retryAfter time.Duration
+		global     bool
+	}{
+		{"body preferred", "2", `{"message": "", "retry_after": 0.25, "global": true}`, 250 * time.Millisecond, true},
+		{"header fallback", "1", `{"message": ""}`, time.Second, false},
+		{"invalid body", "1", `<html></html>`, time.Second, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+				resp := testResponse(http.StatusTooManyRequests, tt.body)
+				resp.Header.Set("Retry-After", tt.header)
+				return resp, nil
+			})
+			session.ShouldRetryOnRateLimit = false
+
+			_, err := session.Channel("channel")
+
+			var rateLimitErr *RateLimitError
+			if !errors.As(err, &rateLimitErr) {
+				t.Fatalf("expected *RateLimitError, got %v", err)
+			}
+			if rateLimitErr.RetryAfter != tt.retryAfter || rateLimitErr.Global != tt.global {
+				t.Errorf("got RetryAfter %v, Global %v, want %v, %v", rateLimitErr.RetryAfter, rateLimitErr.Global, tt.retryAfter, tt.global)
+			}
+		})
+	}
+}
+
+func TestGuildMemberAddComplex(t *testing.T) {
+	status := http.StatusCreated
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		if status == http.StatusNoContent {
+			return testResponse(status, ""), nil
+		}
+		return testResponse(status, `{"user": {"id": "user"}, "nick": "nick"}`), nil
+	})
+
+	member, err := session.GuildMemberAddComplex("guild", "user", &GuildMemberAddParams{AccessToken: "token"})
+	if err != nil {
+		t.Fatalf("GuildMemberAddComplex returned error: %+v", err)
+	}
+	if member.GuildID != "guild" || member.User.ID != "user" || member.Nick != "nick" {
+		t.Errorf("unexpected member %+v", member)
+	}
+
+	status = http.StatusNoContent
+	member, err = session.GuildMemberAddComplex("guild", "user", &GuildMemberAddParams{AccessToken: "token"})
+	if err != ErrGuildMemberExists || member != nil {
+		t.Errorf("got %v, %v, want nil, ErrGuildMemberExists", member, err)
+	}
+}
+
+func TestGuildMembersAll(t *testing.T) {
+	const total = 2500
+	var afters []string
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		afters = append(afters, r.URL.Query().Get("after"))
+		return testUsersPage(r, total), nil
+	})
+
+	members, err := session.GuildMembersAll("guild")
+	if err != nil {
+		t.Fatalf("GuildMembersAll returned error: %+v", err)
+	}
+	if len(members) != total {
+		t.Errorf("got %d members, want %d", len(members), total)
+	}
+	if members[0].GuildID != "guild" {
+		t.Errorf("GuildID = %q, want guild", members[0].GuildID)
+	}
+	if want := []string{"", "1000", "2000"}; strings.Join(afters, ",") != strings.Join(want, ",") {
+		t.Errorf("requested pages after %v, want %v", afters, want)
+	}
+}
+
+func TestGuildBansEach(t *testing.T) {
+	const total = 2500
+	var afters []string
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		afters = append(afters, r.URL.Query().Get("after"))
+		return testUsersPage(r, total), nil
+	})
+
+	tests := []struct {
+		name      string
+		stopAfter string
+		wantBans  int
+		wantPages []string
+	}{
+		{"all", "", total, []string{"", "1000", "2000"}},
+		{"stopped", "1500", 1500, []string{"", "1000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			afters = nil
+			seen := 0
+			err := session.GuildBansEach("guild", func(b *GuildBan) bool {
+				seen++
+				return b.User.ID != tt.stopAfter
+			})
+			if err != nil {
+				t.Fatalf("GuildBansEach returned error: %+v", err)
+			}
+			if seen != tt.wantBans {
+				t.Errorf("got %d bans, want %d", seen, tt.wantBans)
+			}
+			if strings.Join(afters, ",") != strings.Join(tt.wantPages, ",") {
+				t.Errorf("requested pages after %v, want %v", afters, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestSuppressEveryone(t *testing.T) {
+	var sent string
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(r.Body)
+		sent = string(b)
+		return testResponse(http.StatusOK, `{"id": "message"}`), nil
+	})
+
+	if _, err := session.ChannelMessageSend("channel", "@everyone"); err != nil {
+		t.Fatalf("ChannelMessageSend returned error: %+v", err)
+	}
+	if strings.Contains(sent, "allowed_mentions") {
+		t.Errorf("allowed_mentions sent without SuppressEveryone: %s", sent)
+	}
+
+	session.SuppressEveryone = true
+	if _, err := session.ChannelMessageSend("channel", "@everyone"); err != nil {
+		t.Fatalf("ChannelMessageSend returned error: %+v", err)
+	}
+	if !strings.Contains(sent, `"allowed_mentions":{"parse":["users","roles"],"replied_user":true}`) {
+		t.Errorf("@everyone not suppressed: %s", sent)
+	}
+
+	_, err := session.ChannelMessageSendComplex("channel", &MessageSend{
+		Content:         "@everyone",
+		AllowedMentions: &MessageAllowedMentions{Parse: []AllowedMentionType{AllowedMentionTypeEveryone}},
+	})
+	if err != nil {
+		t.Fatalf("ChannelMessageSendComplex returned error: %+v", err)
+	}
+	if !strings.Contains(sent, `"parse":["everyone"]`) {
+		t.Errorf("explicit AllowedMentions not honored: %s", sent)
+	}
+
+	// The caller's structs are left untouched.
+	data := &MessageSend{Content: "@everyone"}
+	webhookData := &WebhookParams{Content: "@everyone"}
+	resp := &InteractionResponse{Type: InteractionResponseChannelMessageWithSource, Data: &InteractionResponseData{Content: "@everyone"}}
+	if _, err = session.ChannelMessageSendComplex("channel", data); err != nil {
+		t.Fatalf("ChannelMessageSendComplex returned error: %+v", err)
+	}
+	if _, err = session.WebhookExecute("webhook", "token", false, webhookData); err != nil {
+		t.Fatalf("WebhookExecute returned error: %+v", err)
+	}
+	if err = session.InteractionRespond(&Interaction{ID: "interaction", Token: "token"}, resp); err != nil {
+		t.Fatalf("InteractionRespond returned error: %+v", err)
+	}
+	if !strings.Contains(sent, `"allowed_mentions":{"parse":["users","roles"],"replied_user":true}`) {
+		t.Errorf("@everyone not suppressed in interaction response: %s", sent)
+	}
+	if data.AllowedMentions != nil || webhookData.AllowedMentions != nil || resp.Data.AllowedMentions != nil {
+		t.Error("AllowedMentions was set on the caller's struct")
+	}
+
+	session.SuppressEveryone = false
+	if _, err = session.ChannelMessageSendComplex("channel", data); err != nil {
+		t.Fatalf("ChannelMessageSendComplex returned error: %+v", err)
+	}
+	if strings.Contains(sent, "allowed_mentions") {
+		t.Errorf("allowed_mentions sent after disabling SuppressEveryone: %s", sent)
+	}
+}
+
+func TestMessageReactionRemoveUserInvalid(t *testing.T) {
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		t.Errorf("unexpected request to %s", r.URL)
+		return testResponse(http.StatusNoContent, ""), nil
+	})
+
+	for _, userID := range []string{"", "@me"} {
+		if err := session.MessageReactionRemoveUser("channel", "message", "👍", userID); err != ErrInvalidUserID {
+			t.Errorf("MessageReactionRemoveUser(%q) err = %v, want ErrInvalidUserID", userID, err)
+		}
+	}
+}
+
+func TestCachedRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		call     func(s *Session) error
+	}{
+		{
+			"GuildMemberCached", `{"user": {"id": "user"}, "nick": "nick"}`,
+			func(s *Session) error {
+				member, err := s.GuildMemberCached("guild", "user")
+				if err == nil && (member.GuildID != "guild" || member.Nick != "nick") {
+					t.Errorf("unexpected member %+v", member)
+				}
+				return err
+			},
+		},
+		{
+			"CurrentUser", `{"id": "bot", "username": "bot"}`,
+			func(s *Session) error {
+				user, err := s.CurrentUser()
+				if err == nil && user.ID != "bot" {
+					t.Errorf("unexpected user %+v", user)
+				}
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+				requests++
+				return testResponse(http.StatusOK, tt.response), nil
+			})
+			session.State.GuildAdd(&Guild{ID: "guild"})
+
+			for i := 0; i < 2; i++ {
+				if err := tt.call(session); err != nil {
+					t.Fatalf("returned error: %+v", err)
+				}
+			}
+			if requests != 1 {
+				t.Errorf("made %d requests, want 1", requests)
+			}
+		})
+	}
+}
+
+func TestCurrentUserStateDisabled(t *testing.T) {
+	requests := 0
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		requests++
+		return testResponse(http.StatusOK, `{"id": "bot", "username": "bot"}`), nil
+	})
+	session.StateEnabled = false
+
+	for i := 0; i < 2; i++ {
+		if _, err := session.CurrentUser(); err != nil {
+			t.Fatalf("CurrentUser returned error: %+v", err)
+		}
+	}
+	if requests != 2 || session.State.User != nil {
+		t.Errorf("made %d requests and cached %+v with the state disabled", requests, session.State.User)
+	}
+}
+
+func TestMessageReactionsAddRateLimited(t *testing.T) {
+	tests := []struct {
+		name    string
+		add     func(s *Session) error
+		segment int // the path segment which differs between the reactions
+		want    string
+	}{
+		{
+			"MessageReactionsAddBulk",
+			func(s *Session) error { return s.MessageReactionsAddBulk("channel", []string{"a", "b", "c"}, "👍") },
+			6, "a,b,c",
+		},
+		{
+			"MessageReactionsAddOrdered",
+			func(s *Session) error {
+				return s.MessageReactionsAddOrdered("channel", "message", []string{"1️⃣", "2️⃣", "3️⃣"})
+			},
+			8, "1️⃣,2️⃣,3️⃣",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reacted []string
+			requests := 0
+			session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+				requests++
+				if requests == 2 {
+					return testRateLimitedResponse("0.01"), nil
+				}
+				reacted = append(reacted, strings.Split(r.URL.Path, "/")[tt.segment])
+				return testResponse(http.StatusNoContent, ""), nil
+			})
+			session.ShouldRetryOnRateLimit = false
+
+			if err := tt.add(session); err != nil {
+				t.Fatalf("returned error: %+v", err)
+			}
+			if got := strings.Join(reacted, ","); got != tt.want {
+				t.Errorf("reacted with %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInteractionDefer(t *testing.T) {
+	var sent string
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(r.Body)
+		sent = string(b)
+		return testResponse(http.StatusNoContent, ""), nil
+	})
+
+	tests := []struct {
+		interactionType InteractionType
+		ephemeral       bool
+		wantType        string
+		wantEphemeral   bool
+	}{
+		{InteractionApplicationCommand, false, `"type":5`, false},
+		{InteractionApplicationCommand, true, `"type":5`, true},
+		{InteractionMessageComponent, true, `"type":6`, false},
+	}
+	for _, tt := range tests {
+		i := &Interaction{ID: "id", Token: "token", Type: tt.interactionType}
+		if err := session.InteractionDefer(i, tt.ephemeral); err != nil {
+			t.Fatalf("InteractionDefer returned error: %+v", err)
+		}
+		if !strings.Contains(sent, tt.wantType) || strings.Contains(sent, `"flags":64`) != tt.wantEphemeral {
+			t.Errorf("InteractionDefer(%v, %v) sent %s", tt.interactionType, tt.ephemeral, sent)
+		}
+	}
+}
+
+func TestMessageReactionsCount(t *testing.T) {
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		return testResponse(http.StatusOK, `{"id": "message", "reactions": [
+			{"count": 3, "emoji": {"name": "👍"}},
+			{"count": 1, "me": true, "emoji": {"id": "1234", "name": "custom"}}
+		]}`), nil
+	})
+
+	counts, err := session.MessageReactionsCount("channel", "message")
+	if err != nil {
+		t.Fatalf("MessageReactionsCount returned error: %+v", err)
+	}
+	if len(counts) != 2 || counts["👍"] != 3 || counts["custom:1234"] != 1 {
+		t.Errorf("MessageReactionsCount = %v", counts)
+	}
+}
+
+func TestBeforeMessageSend(t *testing.T) {
+	var sent string
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(r.Body)
+		sent = string(b)
+		return testResponse(http.StatusOK, `{"id": "message"}`), nil
+	})
+
+	session.BeforeMessageSend = func(channelID string, m *MessageSend) {
+		m.Content += " (sent to " + channelID + ")"
+	}
+
+	data := &MessageSend{Content: "hello"}
+	for i := 0; i < 2; i++ {
+		if _, err := session.ChannelMessageSendComplex("channel", data); err != nil {
+			t.Fatalf("ChannelMessageSendComplex returned error: %+v", err)
+		}
+		if !strings.Contains(sent, `"content":"hello (sent to channel)"`) {
+			t.Errorf("BeforeMessageSend was not applied once: %s", sent)
+		}
+	}
+	if data.Content != "hello" {
+		t.Errorf("caller's MessageSend was modified: %q", data.Content)
+	}
+}
+
+func TestChannelMessageSendNonceRetry(t *testing.T) {
+	var sent []string
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(r.Body)
+		sent = append(sent, string(b))
+		if len(sent)%2 == 1 {
+			return nil, errors.New("connection reset by peer")
+		}
+		return testResponse(http.StatusOK, `{"id": "message"}`), nil
+	})
+
+	if _, err := session.ChannelMessageSend("channel", "hello"); err == nil {
+		t.Fatal("ChannelMessageSend without nonce was retried")
+	}
+
+	// A nonce which is not enforced is sent as is and not retried.
+	sent = nil
+	if _, err := session.ChannelMessageSendComplex("channel", &MessageSend{Content: "hello", Nonce: "nonce"}); err == nil {
+		t.Fatal("ChannelMessageSendComplex without enforced nonce was retried")
+	}
+	if len(sent) != 1 || strings.Contains(sent[0], "enforce_nonce") {
+		t.Errorf("unexpected requests %q", sent)
+	}
+
+	sent = nil
+	_, err := session.ChannelMessageSendComplex("channel", &MessageSend{Content: "hello", Nonce: "nonce", EnforceNonce: true})
+	if err != nil {
+		t.Fatalf("ChannelMessageSendComplex returned error: %+v", err)
+	}
+	if len(sent) != 2 || sent[0] != sent[1] || !strings.Contains(sent[1], `"nonce":"nonce","enforce_nonce":true`) {
+		t.Errorf("unexpected requests %q", sent)
+	}
+}
+
+func TestChannelMessageSendTrackedNonce(t *testing.T) {
+	var nonces []string
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		var sent MessageSend
+		json.NewDecoder(r.Body).Decode(&sent)
+		nonces = append(nonces, sent.Nonce)
+		return testResponse(http.StatusOK, `{"id": "message"}`), nil
+	})
+	session.TrackSentNonces = true
+
+	data := &MessageSend{Content: "hello"}
+	for i := 0; i < 2; i++ {
+		m, err := session.ChannelMessageSendComplex("channel", data)
+		if err != nil {
+			t.Fatalf("ChannelMessageSendComplex returned error: %+v", err)
+		}
+		if m.Nonce == "" || m.Nonce != nonces[i] {
+			t.Errorf("message nonce = %q, want the sent nonce %q", m.Nonce, nonces[i])
+		}
+	}
+	if nonces[0] == nonces[1] {
+		t.Errorf("reused MessageSend was sent with the same nonce %q twice", nonces[0])
+	}
+	if data.Nonce != "" || data.EnforceNonce {
+		t.Errorf("caller's MessageSend was modified: %+v", data)
+	}
+}
+
+func TestAuthorizationPrefix(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"token", "Bot token"},
+		{"Bot token", "Bot token"},
+		{"Bearer token", "Bearer token"},
+	}
+
+	for _, tt := range tests {
+		session, err := New(tt.token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got string
+		session.Client.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			got = r.Header.Get("Authorization")
+			return nil, errors.New("test")
+		})
+		session.User("@me")
+
+		if got != tt.want {
+			t.Errorf("New(%q) sent authorization %q, want %q", tt.token, got, tt.want)
+		}
+	}
+
+	session, err := New("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.ShouldPrefixToken = false
+	if got := session.authorization(session.Token); got != "token" {
+		t.Errorf("authorization with ShouldPrefixToken disabled = %q, want %q", got, "token")
+	}
+}
+
+func TestMessage_MentionedRoles(t *testing.T) {
+	requests := 0
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		requests++
+		if r.URL.String() != EndpointGuildRoles("guild") {
+			t.Errorf("unexpected request to %s", r.URL)
+		}
+		return testResponse(http.StatusOK, `[{"id": "r1", "name": "one"}, {"id": "r2", "name": "two"}, {"id": "r3", "name": "three"}]`), nil
+	})
+	session.State.GuildAdd(&Guild{
+		ID:       "guild",
+		Roles:    []*Role{{ID: "r1", Name: "cached"}},
+		Channels: []*Channel{{ID: "channel", GuildID: "guild"}},
+	})
+
+	m := &Message{ChannelID: "channel", MentionRoles: []string{"r1"}}
+	roles, err := m.MentionedRoles(session)
+	if err != nil {
+		t.Fatalf("MentionedRoles returned error: %+v", err)
+	}
+	if len(roles) != 1 || roles[0].Name != "cached" || requests != 0 {
+		t.Errorf("cached roles = %+v after %d requests", roles, requests)
+	}
+
+	m.MentionRoles = []string{"r2", "deleted", "r1"}
+	roles, err = m.MentionedRoles(session)
+	if err != nil {
+		t.Fatalf("MentionedRoles returned error: %+v", err)
+	}
+	if len(roles) != 2 || roles[0].Name != "two" || roles[1].Name != "one" || requests != 1 {
+		t.Errorf("fetched roles = %+v after %d requests", roles, requests)
+	}
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight int32
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return testResponse(http.StatusOK, `{}`), nil
+	})
+	session.MaxConcurrentRequests = 2
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Distinct channels use distinct buckets, so only the semaphore limits them.
+			var err error
+			if i%2 == 0 {
+				_, err = session.Channel(strconv.Itoa(i))
+			} else {
+				endpoint := EndpointChannel(strconv.Itoa(i))
+				_, err = session.RequestWithLockedBucket("GET", endpoint, "", nil, session.Ratelimiter.LockBucket(endpoint), 0)
+			}
+			if err != nil {
+				t.Errorf("request returned error: %+v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if max := atomic.LoadInt32(&maxInFlight); max != 2 {
+		t.Errorf("%d requests were in flight at once, want 2", max)
+	}
+}
+
+func TestMaxConcurrentRequestsContext(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	session := newTestSession(t, func(r *http.Request) (*http.Response, error) {
+		close(started)
+		<-release
+		return testResponse(http.StatusOK, `{}`), nil
+	})
+	session.MaxConcurrentRequests = 1
+
+	done := make(chan error)
+	go func() {
+		_, err := session.Channel("channel")
+		done <- err
+	}()
+	<-started
+
+	// Waiting for the slot is interrupted by the request's context, and
+	// releases the bucket.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := session.Channel("other", WithContext(ctx)); err != context.DeadlineExceeded {
+		t.Errorf("Channel returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Channel returned error: %+v", err)
+	}
+	session.Ratelimiter.LockBucket(EndpointChannel("other")).Release(nil)
+}
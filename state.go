@@ -16,6 +16,7 @@ import (
 	"errors"
 	"sort"
 	"sync"
+	"time"
 )
 
 // ErrNilState is returned when the state is nil.
@@ -137,6 +138,17 @@ func (s *State) GuildAdd(guild *Guild) error {
 		if guild.VoiceStates == nil {
 			guild.VoiceStates = g.VoiceStates
 		}
+		if guild.Stickers == nil {
+			guild.Stickers = g.Stickers
+		}
+		if guild.StageInstances == nil {
+			guild.StageInstances = g.StageInstances
+		}
+		// JoinedAt and Large are only sent with GUILD_CREATE.
+		if guild.JoinedAt.IsZero() {
+			guild.JoinedAt = g.JoinedAt
+			guild.Large = g.Large
+		}
 		*g = *guild
 		return nil
 	}
@@ -173,6 +185,19 @@ func (s *State) GuildRemove(guild *Guild) error {
 	return nil
 }
 
+// guildUnavailable marks a guild of the state as unavailable.
+func (s *State) guildUnavailable(guildID string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	g, ok := s.guildMap[guildID]
+	if !ok {
+		return ErrStateNotFound
+	}
+	g.Unavailable = true
+	return nil
+}
+
 // Guild gets a guild by ID.
 // Useful for querying if @me is in a guild:
 //     _, err := discordgo.Session.State.Guild(guildID)
@@ -192,6 +217,36 @@ func (s *State) Guild(guildID string) (*Guild, error) {
 	return nil, ErrStateNotFound
 }
 
+// GuildsList returns shallow copies of all the guilds in the state, which
+// are safe to iterate over while the state is being updated.
+func (s *State) GuildsList() []*Guild {
+	if s == nil {
+		return nil
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	guilds := make([]*Guild, len(s.Guilds))
+	for i, g := range s.Guilds {
+		gCopy := *g
+		guilds[i] = &gCopy
+	}
+	return guilds
+}
+
+// GuildCount returns the number of guilds in the state.
+func (s *State) GuildCount() int {
+	if s == nil {
+		return 0
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	return len(s.Guilds)
+}
+
 func (s *State) presenceAdd(guildID string, presence *Presence) error {
 	guild, ok := s.guildMap[guildID]
 	if !ok {
@@ -220,22 +275,7 @@ func (s *State) presenceAdd(guildID string, presence *Presence) error {
 			//Update the optionally sent user information
 			//ID Is a mandatory field so you should not need to check if it is empty
 			guild.Presences[i].User.ID = presence.User.ID
-
-			if presence.User.Avatar != "" {
-				guild.Presences[i].User.Avatar = presence.User.Avatar
-			}
-			if presence.User.Discriminator != "" {
-				guild.Presences[i].User.Discriminator = presence.User.Discriminator
-			}
-			if presence.User.Email != "" {
-				guild.Presences[i].User.Email = presence.User.Email
-			}
-			if presence.User.Token != "" {
-				guild.Presences[i].User.Token = presence.User.Token
-			}
-			if presence.User.Username != "" {
-				guild.Presences[i].User.Username = presence.User.Username
-			}
+			mergePartialUser(guild.Presences[i].User, presence.User)
 
 			return nil
 		}
@@ -245,6 +285,32 @@ func (s *State) presenceAdd(guildID string, presence *Presence) error {
 	return nil
 }
 
+// mergePartialUser copies the fields which are set on a partial user,
+// such as the one sent with a PresenceUpdate, onto user.
+func mergePartialUser(user, partial *User) {
+	if partial.Username != "" {
+		user.Username = partial.Username
+	}
+	if partial.GlobalName != "" {
+		user.GlobalName = partial.GlobalName
+	}
+	if partial.Discriminator != "" {
+		user.Discriminator = partial.Discriminator
+	}
+	if partial.Avatar != "" {
+		user.Avatar = partial.Avatar
+	}
+	if partial.Banner != "" {
+		user.Banner = partial.Banner
+	}
+	if partial.Email != "" {
+		user.Email = partial.Email
+	}
+	if partial.Token != "" {
+		user.Token = partial.Token
+	}
+}
+
 // PresenceAdd adds a presence to the current world state, or
 // updates it if it already exists.
 func (s *State) PresenceAdd(guildID string, presence *Presence) error {
@@ -343,6 +409,30 @@ func (s *State) MemberAdd(member *Member) error {
 	return s.memberAdd(member)
 }
 
+// MembersAdd adds or updates a batch of members of a guild in the current
+// world state, such as the members of a GuildMembersChunk.
+// guildID : The ID of the Guild the members belong to.
+// members : The members to add.
+func (s *State) MembersAdd(guildID string, members []*Member) error {
+	if s == nil {
+		return ErrNilState
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	for _, m := range members {
+		if m == nil || m.User == nil {
+			continue
+		}
+		m.GuildID = guildID
+		if err := s.memberAdd(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // MemberRemove removes a member from current world state.
 func (s *State) MemberRemove(member *Member) error {
 	if s == nil {
@@ -400,6 +490,26 @@ func (s *State) Member(guildID, userID string) (*Member, error) {
 	return nil, ErrStateNotFound
 }
 
+// GuildMembers returns the members of a guild that are held in the state.
+// guildID : The ID of a Guild.
+func (s *State) GuildMembers(guildID string) ([]*Member, error) {
+	if s == nil {
+		return nil, ErrNilState
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	guild, ok := s.guildMap[guildID]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+
+	members := make([]*Member, len(guild.Members))
+	copy(members, guild.Members)
+	return members, nil
+}
+
 // RoleAdd adds a role to the current world state, or
 // updates it if it already exists.
 func (s *State) RoleAdd(guildID string, role *Role) error {
@@ -473,6 +583,117 @@ func (s *State) Role(guildID, roleID string) (*Role, error) {
 	return nil, ErrStateNotFound
 }
 
+// MemberHighestRole returns the highest positioned role of a member in a guild.
+// If the member has no roles, the guild's @everyone role is returned.
+func (s *State) MemberHighestRole(guildID, userID string) (*Role, error) {
+	if s == nil {
+		return nil, ErrNilState
+	}
+
+	guild, err := s.Guild(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.Member(guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	if role := highestRole(guild, member.Roles); role != nil {
+		return role, nil
+	}
+
+	return nil, ErrStateNotFound
+}
+
+// CanManageRole returns whether a user is able to edit, assign or remove
+// a role in a guild. The guild owner can manage every role; anyone else
+// needs the Manage Roles permission and a highest role positioned above it.
+// NOTE: Administrator grants Manage Roles but does not bypass the hierarchy.
+// guildID   : The ID of the guild the role belongs to.
+// botUserID : The ID of the user to check, usually State.User.ID.
+// roleID    : The ID of the role to manage.
+func (s *State) CanManageRole(guildID, botUserID, roleID string) bool {
+	if s == nil {
+		return false
+	}
+
+	guild, err := s.Guild(guildID)
+	if err != nil {
+		return false
+	}
+
+	member, err := s.Member(guildID, botUserID)
+	if err != nil {
+		return false
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	if guild.OwnerID == botUserID {
+		return true
+	}
+
+	var target *Role
+	for _, r := range guild.Roles {
+		if r.ID == roleID {
+			target = r
+			break
+		}
+	}
+	if target == nil {
+		return false
+	}
+
+	var perms int64
+	for _, r := range guild.Roles {
+		if r.ID == guild.ID {
+			perms |= r.Permissions
+			continue
+		}
+		for _, id := range member.Roles {
+			if r.ID == id {
+				perms |= r.Permissions
+				break
+			}
+		}
+	}
+	if perms&(PermissionManageRoles|PermissionAdministrator) == 0 {
+		return false
+	}
+
+	highest := highestRole(guild, member.Roles)
+	return highest != nil && highest.Position > target.Position
+}
+
+// highestRole returns the highest positioned role of the given role IDs,
+// falling back to the guild's @everyone role.
+func highestRole(guild *Guild, memberRoles []string) (highest *Role) {
+	for _, role := range guild.Roles {
+		if role.ID == guild.ID {
+			if highest == nil {
+				highest = role
+			}
+			continue
+		}
+		for _, roleID := range memberRoles {
+			if role.ID == roleID {
+				if highest == nil || highest.ID == guild.ID || role.Position > highest.Position {
+					highest = role
+				}
+				break
+			}
+		}
+	}
+
+	return
+}
+
 // ChannelAdd adds a channel to the current world state, or
 // updates it if it already exists.
 // Channels may exist either as PrivateChannels or inside
@@ -490,6 +711,9 @@ func (s *State) ChannelAdd(channel *Channel) error {
 		if channel.Messages == nil {
 			channel.Messages = c.Messages
 		}
+		if channel.Pins == nil {
+			channel.Pins = c.Pins
+		}
 		if channel.PermissionOverwrites == nil {
 			channel.PermissionOverwrites = c.PermissionOverwrites
 		}
@@ -577,6 +801,101 @@ func (s *State) ChannelRemove(channel *Channel) error {
 	return nil
 }
 
+// ChannelPins returns a copy of the cached pinned messages of a channel.
+// ErrStateNotFound is returned if the pins have not been fetched yet,
+// or were invalidated by a ChannelPinsUpdate event since the last fetch.
+// channelID : The ID of a Channel.
+func (s *State) ChannelPins(channelID string) ([]*Message, error) {
+	if s == nil {
+		return nil, ErrNilState
+	}
+
+	c, err := s.Channel(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	if c.Pins == nil {
+		return nil, ErrStateNotFound
+	}
+
+	pins := make([]*Message, len(c.Pins))
+	copy(pins, c.Pins)
+	return pins, nil
+}
+
+// ChannelPinsSet replaces the cached pinned messages of a channel.
+// channelID : The ID of a Channel.
+// pins      : The pinned messages of the channel.
+func (s *State) ChannelPinsSet(channelID string, pins []*Message) error {
+	if s == nil {
+		return ErrNilState
+	}
+
+	c, err := s.Channel(channelID)
+	if err != nil {
+		return err
+	}
+
+	cached := make([]*Message, len(pins))
+	copy(cached, pins)
+
+	s.Lock()
+	defer s.Unlock()
+
+	c.Pins = cached
+
+	return nil
+}
+
+// channelPinsUpdate updates the last pin timestamp of a channel and
+// invalidates its cached pins, as the event does not carry them.
+func (s *State) channelPinsUpdate(update *ChannelPinsUpdate) error {
+	c, err := s.Channel(update.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	var last *time.Time
+	if update.LastPinTimestamp != "" {
+		t, err := time.Parse(time.RFC3339, update.LastPinTimestamp)
+		if err != nil {
+			return err
+		}
+		last = &t
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	c.LastPinTimestamp = last
+	if last == nil {
+		// No pins remain in the channel.
+		c.Pins = []*Message{}
+	} else {
+		c.Pins = nil
+	}
+
+	return nil
+}
+
+// voiceChannelStatusUpdate updates the status of a cached voice channel.
+func (s *State) voiceChannelStatusUpdate(update *VoiceChannelStatusUpdate) error {
+	c, err := s.Channel(update.ID)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	c.Status = update.Status
+	return nil
+}
+
 // ThreadListSync syncs guild threads with provided ones.
 func (s *State) ThreadListSync(tls *ThreadListSync) error {
 	guild, err := s.Guild(tls.GuildID)
@@ -709,6 +1028,27 @@ func (s *State) Emoji(guildID, emojiID string) (*Emoji, error) {
 	return nil, ErrStateNotFound
 }
 
+// EmojiByID searches all cached guilds for an emoji by ID, returning the
+// emoji and the ID of the guild it belongs to.
+func (s *State) EmojiByID(emojiID string) (emoji *Emoji, guildID string, err error) {
+	if s == nil {
+		return nil, "", ErrNilState
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	for _, guild := range s.Guilds {
+		for _, e := range guild.Emojis {
+			if e.ID == emojiID {
+				return e, guild.ID, nil
+			}
+		}
+	}
+
+	return nil, "", ErrStateNotFound
+}
+
 // EmojiAdd adds an emoji to the current world state.
 func (s *State) EmojiAdd(guildID string, emoji *Emoji) error {
 	if s == nil {
@@ -882,6 +1222,34 @@ func (s *State) VoiceState(guildID, userID string) (*VoiceState, error) {
 	return nil, ErrStateNotFound
 }
 
+// VoiceChannelMembers returns copies of the voice states of all users
+// connected to the given voice channel.
+// guildID   : The ID of a Guild.
+// channelID : The ID of a voice Channel.
+func (s *State) VoiceChannelMembers(guildID, channelID string) ([]*VoiceState, error) {
+	if s == nil {
+		return nil, ErrNilState
+	}
+
+	guild, err := s.Guild(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	states := []*VoiceState{}
+	for _, state := range guild.VoiceStates {
+		if state.ChannelID == channelID {
+			stateCopy := *state
+			states = append(states, &stateCopy)
+		}
+	}
+
+	return states, nil
+}
+
 // Message gets a message by channel and message ID.
 func (s *State) Message(channelID, messageID string) (*Message, error) {
 	if s == nil {
@@ -905,6 +1273,76 @@ func (s *State) Message(channelID, messageID string) (*Message, error) {
 	return nil, ErrStateNotFound
 }
 
+// GuildSnapshot is a point-in-time summary of a cached guild.
+type GuildSnapshot struct {
+	ID   string
+	Name string
+
+	// MemberCount is the member count reported by Discord, while
+	// Members is the number of members held in the state.
+	MemberCount int
+	Members     int
+
+	Channels    int
+	Threads     int
+	Roles       int
+	Emojis      int
+	Presences   int
+	VoiceStates int
+}
+
+// StateSnapshot is a consistent point-in-time summary of the State.
+// It holds only counts and is safe to use without locking.
+type StateSnapshot struct {
+	Guilds          []GuildSnapshot
+	PrivateChannels int
+
+	// Totals across all guilds.
+	Channels int
+	Threads  int
+	Members  int
+	Roles    int
+}
+
+// Snapshot returns a consistent summary of the cached guilds, taken
+// while holding the state lock, so that counts are never read mid-update.
+func (s *State) Snapshot() StateSnapshot {
+	if s == nil {
+		return StateSnapshot{}
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	snap := StateSnapshot{
+		Guilds:          make([]GuildSnapshot, 0, len(s.Guilds)),
+		PrivateChannels: len(s.PrivateChannels),
+	}
+
+	for _, g := range s.Guilds {
+		gs := GuildSnapshot{
+			ID:          g.ID,
+			Name:        g.Name,
+			MemberCount: g.MemberCount,
+			Members:     len(g.Members),
+			Channels:    len(g.Channels),
+			Threads:     len(g.Threads),
+			Roles:       len(g.Roles),
+			Emojis:      len(g.Emojis),
+			Presences:   len(g.Presences),
+			VoiceStates: len(g.VoiceStates),
+		}
+		snap.Guilds = append(snap.Guilds, gs)
+
+		snap.Channels += gs.Channels
+		snap.Threads += gs.Threads
+		snap.Members += gs.Members
+		snap.Roles += gs.Roles
+	}
+
+	return snap
+}
+
 // OnReady takes a Ready event and updates all internal state.
 func (s *State) onReady(se *Session, r *Ready) (err error) {
 	if s == nil {
@@ -976,7 +1414,13 @@ func (s *State) OnInterface(se *Session, i interface{}) (err error) {
 			t.BeforeDelete = &oldCopy
 		}
 
-		err = s.GuildRemove(t.Guild)
+		// An unavailable guild is still joined, it is sent again with
+		// GUILD_CREATE once the outage is over.
+		if t.Unavailable {
+			err = s.guildUnavailable(t.ID)
+		} else {
+			err = s.GuildRemove(t.Guild)
+		}
 	case *GuildMemberAdd:
 		var guild *Guild
 		// Updates the MemberCount of the guild.
@@ -1016,15 +1460,14 @@ func (s *State) OnInterface(se *Session, i interface{}) (err error) {
 		}
 	case *GuildMembersChunk:
 		if s.TrackMembers {
-			for i := range t.Members {
-				t.Members[i].GuildID = t.GuildID
-				err = s.MemberAdd(t.Members[i])
-			}
+			err = s.MembersAdd(t.GuildID, t.Members)
 		}
 
 		if s.TrackPresences {
 			for _, p := range t.Presences {
-				err = s.PresenceAdd(t.GuildID, p)
+				if perr := s.PresenceAdd(t.GuildID, p); perr != nil && err == nil {
+					err = perr
+				}
 			}
 		}
 	case *GuildRoleCreate:
@@ -1067,6 +1510,14 @@ func (s *State) OnInterface(se *Session, i interface{}) (err error) {
 		if s.TrackChannels {
 			err = s.ChannelRemove(t.Channel)
 		}
+	case *ChannelPinsUpdate:
+		if s.TrackChannels {
+			err = s.channelPinsUpdate(t)
+		}
+	case *VoiceChannelStatusUpdate:
+		if s.TrackChannels {
+			err = s.voiceChannelStatusUpdate(t)
+		}
 	case *ThreadCreate:
 		if s.TrackThreads {
 			err = s.ChannelAdd(t.Channel)
@@ -1158,9 +1609,16 @@ func (s *State) OnInterface(se *Session, i interface{}) (err error) {
 					User:    t.User,
 				}
 			} else {
-				if t.User.Username != "" {
-					m.User.Username = t.User.Username
-				}
+				// The user is partial, so merge it into a copy of the
+				// cached member instead of replacing the cached user.
+				s.RLock()
+				member := *m
+				user := *m.User
+				s.RUnlock()
+
+				mergePartialUser(&user, t.User)
+				member.User = &user
+				m = &member
 			}
 
 			err = s.MemberAdd(m)
@@ -1197,6 +1655,35 @@ func (s *State) UserChannelPermissions(userID, channelID string) (apermissions i
 	return memberPermissions(guild, channel, userID, member.Roles), nil
 }
 
+// CanSendMessages returns whether the session user can send messages in a
+// channel, according to its permissions computed from the state.
+// Threads are checked against the permissions in their parent channel.
+// channelID : The ID of a Channel.
+func (s *State) CanSendMessages(channelID string) bool {
+	if s == nil || s.User == nil {
+		return false
+	}
+
+	channel, err := s.Channel(channelID)
+	if err != nil {
+		return false
+	}
+
+	// Permissions do not apply to private channels.
+	if channel.GuildID == "" {
+		return true
+	}
+
+	needed := int64(PermissionViewChannel | PermissionSendMessages)
+	if channel.IsThread() {
+		channelID = channel.ParentID
+		needed = PermissionViewChannel | PermissionSendMessagesInThreads
+	}
+
+	perms, err := s.UserChannelPermissions(s.User.ID, channelID)
+	return err == nil && perms&needed == needed
+}
+
 // MessagePermissions returns the permissions of the author of the message
 // in the channel in which it was sent.
 func (s *State) MessagePermissions(message *Message) (apermissions int64, err error) {
@@ -0,0 +1,404 @@
+package discordgo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateRoleHierarchy(t *testing.T) {
+	state := NewState()
+	state.GuildAdd(&Guild{
+		ID:      "guild",
+		OwnerID: "owner",
+		Roles: []*Role{
+			{ID: "guild", Position: 0},
+			{ID: "mod", Position: 2, Permissions: PermissionManageRoles},
+			{ID: "member", Position: 1},
+			{ID: "admin", Position: 3, Permissions: PermissionAdministrator},
+		},
+	})
+	state.MemberAdd(&Member{GuildID: "guild", User: &User{ID: "owner"}})
+	state.MemberAdd(&Member{GuildID: "guild", User: &User{ID: "bot"}, Roles: []string{"member", "mod"}})
+	state.MemberAdd(&Member{GuildID: "guild", User: &User{ID: "user"}, Roles: []string{"member"}})
+
+	role, err := state.MemberHighestRole("guild", "bot")
+	if err != nil || role.ID != "mod" {
+		t.Errorf("MemberHighestRole(bot) = %v, %v, want mod", role, err)
+	}
+	role, err = state.MemberHighestRole("guild", "owner")
+	if err != nil || role.ID != "guild" {
+		t.Errorf("MemberHighestRole(owner) = %v, %v, want @everyone", role, err)
+	}
+
+	tests := []struct {
+		userID string
+		roleID string
+		want   bool
+	}{
+		{"bot", "member", true},
+		{"bot", "mod", false},
+		{"bot", "admin", false},
+		{"user", "guild", false},
+		{"owner", "admin", true},
+		{"bot", "unknown", false},
+	}
+	for _, tt := range tests {
+		if got := state.CanManageRole("guild", tt.userID, tt.roleID); got != tt.want {
+			t.Errorf("CanManageRole(%s, %s) = %v, want %v", tt.userID, tt.roleID, got, tt.want)
+		}
+	}
+}
+
+func TestStateChannelPins(t *testing.T) {
+	session := &Session{StateEnabled: true, State: NewState()}
+	state := session.State
+	state.GuildAdd(&Guild{ID: "guild"})
+	state.ChannelAdd(&Channel{ID: "channel", GuildID: "guild"})
+
+	if _, err := state.ChannelPins("channel"); err != ErrStateNotFound {
+		t.Fatalf("ChannelPins before fetch: got %v, want ErrStateNotFound", err)
+	}
+
+	pins := []*Message{{ID: "pinned", ChannelID: "channel"}}
+	if err := state.ChannelPinsSet("channel", pins); err != nil {
+		t.Fatalf("ChannelPinsSet: %v", err)
+	}
+	got, err := state.ChannelPins("channel")
+	if err != nil || len(got) != 1 || got[0].ID != "pinned" {
+		t.Fatalf("ChannelPins = %v, %v, want cached pins", got, err)
+	}
+	got[0] = nil
+	pins[0] = nil
+	if got, _ = state.ChannelPins("channel"); got[0] == nil {
+		t.Error("ChannelPins shares its slice with the cache")
+	}
+
+	err = state.OnInterface(session, &ChannelPinsUpdate{ChannelID: "channel", GuildID: "guild", LastPinTimestamp: "2021-01-01T00:00:00Z"})
+	if err != nil {
+		t.Fatalf("OnInterface: %v", err)
+	}
+	c, _ := state.Channel("channel")
+	if c.LastPinTimestamp == nil || c.LastPinTimestamp.Year() != 2021 {
+		t.Errorf("LastPinTimestamp = %v, want 2021-01-01", c.LastPinTimestamp)
+	}
+	if _, err := state.ChannelPins("channel"); err != ErrStateNotFound {
+		t.Errorf("ChannelPins after update: got %v, want ErrStateNotFound", err)
+	}
+
+	state.OnInterface(session, &ChannelPinsUpdate{ChannelID: "channel", GuildID: "guild"})
+	got, err = state.ChannelPins("channel")
+	if err != nil || len(got) != 0 || c.LastPinTimestamp != nil {
+		t.Errorf("ChannelPins after last unpin = %v, %v, want empty", got, err)
+	}
+}
+
+func TestStateSnapshot(t *testing.T) {
+	state := NewState()
+	state.GuildAdd(&Guild{
+		ID:          "a",
+		MemberCount: 10,
+		Roles:       []*Role{{ID: "a"}, {ID: "mod"}},
+		Channels:    []*Channel{{ID: "a1", GuildID: "a"}, {ID: "a2", GuildID: "a"}},
+		Members:     []*Member{{GuildID: "a", User: &User{ID: "u1"}}},
+	})
+	state.GuildAdd(&Guild{
+		ID:       "b",
+		Roles:    []*Role{{ID: "b"}},
+		Channels: []*Channel{{ID: "b1", GuildID: "b"}},
+		Threads:  []*Channel{{ID: "b2", GuildID: "b", Type: ChannelTypeGuildPublicThread}},
+		Members:  []*Member{{GuildID: "b", User: &User{ID: "u1"}}, {GuildID: "b", User: &User{ID: "u2"}}},
+	})
+
+	snap := state.Snapshot()
+	if len(snap.Guilds) != 2 {
+		t.Fatalf("len(Guilds) = %d, want 2", len(snap.Guilds))
+	}
+	if g := snap.Guilds[0]; g.ID != "a" || g.MemberCount != 10 || g.Members != 1 || g.Channels != 2 || g.Roles != 2 {
+		t.Errorf("Guilds[0] = %+v", g)
+	}
+	if snap.Channels != 3 || snap.Threads != 1 || snap.Members != 3 || snap.Roles != 3 {
+		t.Errorf("totals = %+v", snap)
+	}
+
+	state.GuildRemove(&Guild{ID: "b"})
+	if snap.Guilds[1].Members != 2 {
+		t.Errorf("snapshot changed after state update: %+v", snap.Guilds[1])
+	}
+}
+
+func TestStateVoiceChannelMembers(t *testing.T) {
+	session := &Session{StateEnabled: true, State: NewState()}
+	state := session.State
+	state.GuildAdd(&Guild{ID: "guild"})
+
+	for _, vs := range []*VoiceState{
+		{GuildID: "guild", UserID: "a", ChannelID: "voice"},
+		{GuildID: "guild", UserID: "b", ChannelID: "other"},
+		{GuildID: "guild", UserID: "c", ChannelID: "voice"},
+	} {
+		state.OnInterface(session, &VoiceStateUpdate{VoiceState: vs})
+	}
+
+	members, err := state.VoiceChannelMembers("guild", "voice")
+	if err != nil {
+		t.Fatalf("VoiceChannelMembers: %v", err)
+	}
+	if len(members) != 2 || members[0].UserID != "a" || members[1].UserID != "c" {
+		t.Errorf("VoiceChannelMembers = %+v, want users a and c", members)
+	}
+
+	// Leaving the channel must not affect the returned copies.
+	state.OnInterface(session, &VoiceStateUpdate{VoiceState: &VoiceState{GuildID: "guild", UserID: "a"}})
+	if members[0].ChannelID != "voice" {
+		t.Errorf("returned voice state was modified: %+v", members[0])
+	}
+	members, _ = state.VoiceChannelMembers("guild", "voice")
+	if len(members) != 1 || members[0].UserID != "c" {
+		t.Errorf("VoiceChannelMembers after leave = %+v, want user c", members)
+	}
+
+	if _, err := state.VoiceChannelMembers("unknown", "voice"); err != ErrStateNotFound {
+		t.Errorf("VoiceChannelMembers(unknown guild) error = %v, want ErrStateNotFound", err)
+	}
+}
+
+func TestStateEmojiByID(t *testing.T) {
+	state := NewState()
+	state.GuildAdd(&Guild{ID: "a", Emojis: []*Emoji{{ID: "1", Name: "one"}}})
+	state.GuildAdd(&Guild{ID: "b", Emojis: []*Emoji{{ID: "2", Name: "two"}}})
+
+	emoji, guildID, err := state.EmojiByID("2")
+	if err != nil || emoji.Name != "two" || guildID != "b" {
+		t.Errorf("EmojiByID(2) = %v, %q, %v, want two in guild b", emoji, guildID, err)
+	}
+
+	if _, _, err = state.EmojiByID("3"); err != ErrStateNotFound {
+		t.Errorf("EmojiByID(3) error = %v, want ErrStateNotFound", err)
+	}
+}
+
+func TestStatePresenceUpdatePartialUser(t *testing.T) {
+	session := &Session{StateEnabled: true, State: NewState()}
+	state := session.State
+	state.GuildAdd(&Guild{ID: "guild"})
+	state.MemberAdd(&Member{
+		GuildID: "guild",
+		Nick:    "nick",
+		User:    &User{ID: "user", Username: "name", GlobalName: "Name", Avatar: "avatar", Discriminator: "0"},
+	})
+	state.PresenceAdd("guild", &Presence{User: &User{ID: "user", Username: "name"}, Status: StatusOnline})
+
+	err := state.OnInterface(session, &PresenceUpdate{
+		GuildID:  "guild",
+		Presence: Presence{User: &User{ID: "user", Avatar: "new"}, Status: StatusIdle},
+	})
+	if err != nil {
+		t.Fatalf("OnInterface: %v", err)
+	}
+
+	m, err := state.Member("guild", "user")
+	if err != nil {
+		t.Fatalf("Member: %v", err)
+	}
+	if m.Nick != "nick" || m.User.Username != "name" || m.User.GlobalName != "Name" || m.User.Avatar != "new" {
+		t.Errorf("member after presence update = %+v, user %+v", m, m.User)
+	}
+
+	p, err := state.Presence("guild", "user")
+	if err != nil {
+		t.Fatalf("Presence: %v", err)
+	}
+	if p.Status != StatusIdle || p.User.Username != "name" || p.User.Avatar != "new" {
+		t.Errorf("presence after update = %+v, user %+v", p, p.User)
+	}
+}
+
+func TestStateGuildMembersChunk(t *testing.T) {
+	session := &Session{StateEnabled: true, State: NewState()}
+	state := session.State
+	state.GuildAdd(&Guild{ID: "g", Members: []*Member{{GuildID: "g", User: &User{ID: "u1"}}}})
+
+	chunks := []*GuildMembersChunk{
+		{GuildID: "g", ChunkIndex: 0, ChunkCount: 2, Members: []*Member{{User: &User{ID: "u1", Username: "one"}}, {User: &User{ID: "u2"}}}},
+		{GuildID: "g", ChunkIndex: 1, ChunkCount: 2, Members: []*Member{{User: &User{ID: "u3"}}},
+			Presences: []*Presence{{User: &User{ID: "u3"}, Status: StatusOnline}}},
+	}
+	for _, c := range chunks {
+		if err := state.OnInterface(session, c); err != nil {
+			t.Fatalf("OnInterface: %v", err)
+		}
+	}
+
+	members, err := state.GuildMembers("g")
+	if err != nil {
+		t.Fatalf("GuildMembers: %v", err)
+	}
+	if len(members) != 3 {
+		t.Fatalf("len(members) = %d, want 3", len(members))
+	}
+	if m, err := state.Member("g", "u1"); err != nil || m.User.Username != "one" {
+		t.Errorf("Member(u1) = %+v, %v", m, err)
+	}
+	if m, err := state.Member("g", "u3"); err != nil || m.GuildID != "g" {
+		t.Errorf("Member(u3) = %+v, %v", m, err)
+	}
+	if p, err := state.Presence("g", "u3"); err != nil || p.Status != StatusOnline {
+		t.Errorf("Presence(u3) = %+v, %v", p, err)
+	}
+
+	state.TrackMembers = false
+	state.OnInterface(session, &GuildMembersChunk{GuildID: "g", Members: []*Member{{User: &User{ID: "u4"}}}})
+	if _, err := state.Member("g", "u4"); err != ErrStateNotFound {
+		t.Errorf("Member(u4) err = %v, want ErrStateNotFound", err)
+	}
+
+	if _, err := state.GuildMembers("missing"); err != ErrStateNotFound {
+		t.Errorf("GuildMembers(missing) err = %v, want ErrStateNotFound", err)
+	}
+}
+
+func TestStateGuildUpdatePreservesCaches(t *testing.T) {
+	session := &Session{StateEnabled: true, State: NewState()}
+	state := session.State
+	joined := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	state.OnInterface(session, &GuildCreate{Guild: &Guild{
+		ID:             "g",
+		Name:           "old",
+		JoinedAt:       joined,
+		Large:          true,
+		MemberCount:    2,
+		Members:        []*Member{{GuildID: "g", User: &User{ID: "u1"}}, {GuildID: "g", User: &User{ID: "u2"}}},
+		Channels:       []*Channel{{ID: "c", GuildID: "g"}},
+		Roles:          []*Role{{ID: "r"}},
+		Emojis:         []*Emoji{{ID: "e"}},
+		Stickers:       []*Sticker{{ID: "s"}},
+		Presences:      []*Presence{{User: &User{ID: "u1"}}},
+		VoiceStates:    []*VoiceState{{GuildID: "g", UserID: "u1", ChannelID: "c"}},
+		StageInstances: []*StageInstance{{ID: "st"}},
+	}})
+
+	if err := state.OnInterface(session, &GuildUpdate{Guild: &Guild{ID: "g", Name: "new", Roles: []*Role{{ID: "r"}, {ID: "r2"}}}}); err != nil {
+		t.Fatalf("GuildUpdate: %v", err)
+	}
+
+	g, err := state.Guild("g")
+	if err != nil {
+		t.Fatalf("Guild: %v", err)
+	}
+	if g.Name != "new" || len(g.Roles) != 2 {
+		t.Errorf("updated fields not applied: name %q, %d roles", g.Name, len(g.Roles))
+	}
+	if len(g.Members) != 2 || len(g.Channels) != 1 || len(g.Emojis) != 1 || len(g.Stickers) != 1 ||
+		len(g.Presences) != 1 || len(g.VoiceStates) != 1 || len(g.StageInstances) != 1 {
+		t.Errorf("cached slices were not preserved: %+v", g)
+	}
+	if g.MemberCount != 2 || !g.JoinedAt.Equal(joined) || !g.Large {
+		t.Errorf("GUILD_CREATE only fields were not preserved: %+v", g)
+	}
+	if _, err := state.Member("g", "u2"); err != nil {
+		t.Errorf("Member(u2): %v", err)
+	}
+	if _, err := state.Channel("c"); err != nil {
+		t.Errorf("Channel(c): %v", err)
+	}
+}
+
+func TestStateGuildDeleteUnavailable(t *testing.T) {
+	session := &Session{StateEnabled: true, State: NewState()}
+	state := session.State
+	state.GuildAdd(&Guild{ID: "g", Members: []*Member{{GuildID: "g", User: &User{ID: "u"}}}})
+
+	if err := state.OnInterface(session, &GuildDelete{Guild: &Guild{ID: "g", Unavailable: true}}); err != nil {
+		t.Fatalf("GuildDelete (unavailable): %v", err)
+	}
+	g, err := state.Guild("g")
+	if err != nil {
+		t.Fatalf("guild removed during an outage: %v", err)
+	}
+	if !g.Unavailable || len(g.Members) != 1 {
+		t.Errorf("guild = %+v, want it unavailable with its members", g)
+	}
+
+	if err := state.OnInterface(session, &GuildDelete{Guild: &Guild{ID: "g"}}); err != nil {
+		t.Fatalf("GuildDelete: %v", err)
+	}
+	if _, err := state.Guild("g"); err != ErrStateNotFound {
+		t.Errorf("Guild after removal err = %v, want ErrStateNotFound", err)
+	}
+}
+
+func TestStateGuildsList(t *testing.T) {
+	state := NewState()
+	state.GuildAdd(&Guild{ID: "a", Name: "A"})
+	state.GuildAdd(&Guild{ID: "b", Name: "B"})
+
+	if n := state.GuildCount(); n != 2 {
+		t.Errorf("GuildCount() = %d, want 2", n)
+	}
+
+	guilds := state.GuildsList()
+	if len(guilds) != 2 || guilds[0].ID != "a" || guilds[1].ID != "b" {
+		t.Fatalf("GuildsList() = %+v", guilds)
+	}
+
+	guilds[0].Name = "changed"
+	if g, _ := state.Guild("a"); g.Name != "A" {
+		t.Errorf("modifying the returned guild changed the state: %q", g.Name)
+	}
+
+	state.GuildRemove(&Guild{ID: "b"})
+	if len(guilds) != 2 || state.GuildCount() != 1 {
+		t.Errorf("list = %d guilds, count = %d after removal", len(guilds), state.GuildCount())
+	}
+}
+
+func TestStateVoiceChannelStatusUpdate(t *testing.T) {
+	session := &Session{StateEnabled: true, State: NewState()}
+	state := session.State
+	state.GuildAdd(&Guild{ID: "g", Channels: []*Channel{{ID: "v", GuildID: "g", Type: ChannelTypeGuildVoice}}})
+
+	if err := state.OnInterface(session, &VoiceChannelStatusUpdate{ID: "v", GuildID: "g", Status: "Now playing"}); err != nil {
+		t.Fatalf("VoiceChannelStatusUpdate: %v", err)
+	}
+	if c, _ := state.Channel("v"); c.Status != "Now playing" {
+		t.Errorf("Status = %q, want %q", c.Status, "Now playing")
+	}
+}
+
+func TestStateCanSendMessages(t *testing.T) {
+	state := NewState()
+	state.User = &User{ID: "bot"}
+	state.GuildAdd(&Guild{
+		ID:    "g",
+		Roles: []*Role{{ID: "g", Permissions: PermissionViewChannel | PermissionSendMessages}},
+		Channels: []*Channel{
+			{ID: "open", GuildID: "g"},
+			{ID: "readonly", GuildID: "g", PermissionOverwrites: []*PermissionOverwrite{
+				{ID: "g", Type: PermissionOverwriteTypeRole, Deny: PermissionSendMessages},
+			}},
+			{ID: "threads", GuildID: "g", PermissionOverwrites: []*PermissionOverwrite{
+				{ID: "bot", Type: PermissionOverwriteTypeMember, Allow: PermissionSendMessagesInThreads},
+			}},
+		},
+		Threads: []*Channel{
+			{ID: "open-thread", GuildID: "g", ParentID: "open", Type: ChannelTypeGuildPublicThread},
+			{ID: "thread", GuildID: "g", ParentID: "threads", Type: ChannelTypeGuildPublicThread},
+		},
+		Members: []*Member{{GuildID: "g", User: &User{ID: "bot"}}},
+	})
+	state.ChannelAdd(&Channel{ID: "dm", Type: ChannelTypeDM})
+
+	tests := map[string]bool{
+		"open":        true,
+		"readonly":    false,
+		"open-thread": false,
+		"thread":      true,
+		"dm":          true,
+		"missing":     false,
+	}
+	for channelID, want := range tests {
+		if got := state.CanSendMessages(channelID); got != want {
+			t.Errorf("CanSendMessages(%q) = %v, want %v", channelID, got, want)
+		}
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"reflect"
 	"regexp"
 	"sync"
 	"time"
@@ -48,6 +49,49 @@ type Session struct {
 	// Should the session retry requests when rate limited.
 	ShouldRetryOnRateLimit bool
 
+	// The maximum number of REST requests in flight at the same time,
+	// further requests wait for one of them to complete, or for their
+	// context to be done. A request is in flight from when its ratelimit
+	// bucket allows it until its response body is read, and each retry
+	// is a request of its own. Zero or less means no limit.
+	MaxConcurrentRequests int
+
+	// How long ChannelVoiceJoin waits for the voice connection to be
+	// ready, defaults to 10 seconds when zero.
+	VoiceJoinTimeout time.Duration
+
+	// Should messages sent by the session never mention @everyone and @here.
+	// Messages sent without AllowedMentions are sent allowing only user and
+	// role mentions, while an explicit AllowedMentions is always honored.
+	SuppressEveryone bool
+
+	// Should ChannelMessageSendComplex set a random Nonce on messages sent
+	// without one, and should the messages echoed by the gateway with a
+	// nonce be remembered, so WaitForMessage returns them even when they
+	// arrived before it was called.
+	TrackSentNonces bool
+
+	// The maximum size in bytes of a file sent with ChannelMessageSendComplex,
+	// larger files fail before they are uploaded. Zero means no check, as the
+	// upload limit depends on the guild's boost level.
+	MaxUploadSize int64
+
+	// BeforeMessageSend, when set, is called by ChannelMessageSendComplex
+	// with every message before it is sent, and may modify it. It is given a
+	// shallow copy of the caller's MessageSend, so nested values such as
+	// embeds must be replaced rather than modified.
+	BeforeMessageSend func(channelID string, m *MessageSend)
+
+	// GatewayWriteHook, when set, is called with the opcode and the payload
+	// of every message before it is written to the gateway websocket,
+	// including heartbeats and the identify payload, which holds the token.
+	// It may be called concurrently and must not modify the payload.
+	GatewayWriteHook func(op int, data interface{})
+
+	// Should the session send tokens without a "Bot " or "Bearer " prefix
+	// as bot tokens, by adding the "Bot " prefix to REST and gateway requests.
+	ShouldPrefixToken bool
+
 	// Identify is sent during initial handshake with the discord gateway.
 	// https://discord.com/developers/docs/topics/gateway#identify
 	Identify Identify
@@ -74,11 +118,12 @@ type Session struct {
 	// Whether the Data Websocket is ready
 	DataReady bool // NOTE: Maye be deprecated soon
 
-	// Max number of REST API retries
+	// Max number of REST API retries, both of requests failing with a
+	// 502 and of rate limited requests when ShouldRetryOnRateLimit is set.
+	// A request rate limited more times returns a *RateLimitError.
 	MaxRestRetries int
 
-	// Status stores the current status of the websocket connection
-	// this is being tested, may stay, may go away.
+	// status stores the current ConnectionStatus of the websocket connection.
 	status int32
 
 	// Whether the Voice Websocket is ready
@@ -98,6 +143,8 @@ type Session struct {
 	Client *http.Client
 
 	// The dialer used for WebSocket connection
+	// Both the gateway and voice websockets are opened with it, so setting
+	// its Proxy routes them through a proxy (defaults to the environment).
 	Dialer *websocket.Dialer
 
 	// The user agent used for REST APIs
@@ -112,11 +159,32 @@ type Session struct {
 	// used to deal with rate limits
 	Ratelimiter *RateLimiter
 
+	// limits the REST requests in flight to MaxConcurrentRequests
+	requestSemMu sync.Mutex
+	requestSem   chan struct{}
+
 	// Event handlers
 	handlersMu   sync.RWMutex
 	handlers     map[string][]*eventHandlerInstance
 	onceHandlers map[string][]*eventHandlerInstance
 
+	// event types registered at runtime with RegisterEventType
+	customEventTypes map[string]reflect.Type
+
+	// recently echoed messages with a nonce, tracked when TrackSentNonces is set
+	noncesMu      sync.Mutex
+	nonceMessages map[string]*Message
+	nonceOrder    []string
+
+	// tracks event handlers running in their own goroutines
+	handlersWg sync.WaitGroup
+
+	// number of CloseGracefully calls waiting for handlersWg, guarded by handlersMu
+	handlersClosing int
+
+	// Event middlewares, called in order before handlers
+	middlewares []*middlewareInstance
+
 	// The websocket connection.
 	wsConn *websocket.Conn
 
@@ -134,6 +202,9 @@ type Session struct {
 
 	// used to make sure gateway websocket writes do not happen concurrently
 	wsMutex sync.Mutex
+
+	// paces gateway commands to stay under Discord's gateway ratelimit
+	gatewayLimiter gatewayRateLimiter
 }
 
 // ApplicationIntegrationType dictates where application can be installed and its available interaction contexts.
@@ -405,12 +476,21 @@ type Channel struct {
 	// and State.MaxMessageCount must be non-zero.
 	Messages []*Message `json:"-"`
 
+	// The pinned messages in the channel. This is only present in state-cached
+	// channels once the pins have been fetched with Session.ChannelMessagesPinned,
+	// and is cleared whenever a ChannelPinsUpdate event invalidates it.
+	Pins []*Message `json:"-"`
+
 	// A list of permission overwrites present for the channel.
 	PermissionOverwrites []*PermissionOverwrite `json:"permission_overwrites"`
 
 	// The user limit of the voice channel.
 	UserLimit int `json:"user_limit"`
 
+	// The status of the voice channel, shown below its name.
+	// Use Session.ChannelVoiceStatusEdit to change it.
+	Status string `json:"status,omitempty"`
+
 	// The ID of the parent channel, if the channel is under a category. For threads - id of the channel thread was created in.
 	ParentID string `json:"parent_id"`
 
@@ -639,6 +719,19 @@ func (e *Emoji) APIName() string {
 	return e.ID
 }
 
+// URL returns the CDN URL of the emoji image, which is a GIF for
+// animated emojis and a PNG otherwise.
+// Unicode emojis have no image and return an empty string.
+func (e *Emoji) URL() string {
+	if e.ID == "" {
+		return ""
+	}
+	if e.Animated {
+		return EndpointEmojiAnimated(e.ID)
+	}
+	return EndpointEmoji(e.ID)
+}
+
 // EmojiParams represents parameters needed to create or update an Emoji.
 type EmojiParams struct {
 	// Name of the emoji
@@ -686,6 +779,12 @@ type Sticker struct {
 	SortValue   int           `json:"sort_value"`
 }
 
+// URL returns the URL of the sticker file, whose extension
+// and host depend on the sticker format.
+func (s *Sticker) URL() string {
+	return stickerURL(s.ID, s.FormatType)
+}
+
 // StickerItem represents the smallest amount of data required to render a sticker. A partial sticker object.
 type StickerItem struct {
 	ID         string        `json:"id"`
@@ -693,6 +792,24 @@ type StickerItem struct {
 	FormatType StickerFormat `json:"format_type"`
 }
 
+// URL returns the URL of the sticker file, whose extension
+// and host depend on the sticker format.
+func (s *StickerItem) URL() string {
+	return stickerURL(s.ID, s.FormatType)
+}
+
+func stickerURL(stickerID string, format StickerFormat) string {
+	switch format {
+	case StickerFormatTypeLottie:
+		return EndpointStickerImage(stickerID, "json")
+	case StickerFormatTypeGIF:
+		// GIF stickers are only served from the media proxy.
+		return EndpointStickerImageGIF(stickerID)
+	default:
+		return EndpointStickerImage(stickerID, "png")
+	}
+}
+
 // StickerPack represents a pack of standard stickers.
 type StickerPack struct {
 	ID             string     `json:"id"`
@@ -1280,6 +1397,16 @@ const (
 	SystemChannelFlagsSuppressJoinNotificationReplies    SystemChannelFlag = 1 << 3
 )
 
+// HasFeature returns whether the guild has the given feature enabled.
+func (g *Guild) HasFeature(feature GuildFeature) bool {
+	for _, f := range g.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
 // IconURL returns a URL to the guild's icon.
 //
 //	size:    The size of the desired icon image as a power of two
@@ -1344,26 +1471,26 @@ const (
 
 // A GuildParams stores all the data needed to update discord guild settings
 type GuildParams struct {
-	Name                        string             `json:"name,omitempty"`
-	Region                      string             `json:"region,omitempty"`
-	VerificationLevel           *VerificationLevel `json:"verification_level,omitempty"`
-	DefaultMessageNotifications int                `json:"default_message_notifications,omitempty"` // TODO: Separate type?
-	ExplicitContentFilter       int                `json:"explicit_content_filter,omitempty"`
-	AfkChannelID                string             `json:"afk_channel_id,omitempty"`
-	AfkTimeout                  int                `json:"afk_timeout,omitempty"`
-	Icon                        string             `json:"icon,omitempty"`
-	OwnerID                     string             `json:"owner_id,omitempty"`
-	Splash                      string             `json:"splash,omitempty"`
-	DiscoverySplash             string             `json:"discovery_splash,omitempty"`
-	Banner                      string             `json:"banner,omitempty"`
-	SystemChannelID             string             `json:"system_channel_id,omitempty"`
-	SystemChannelFlags          SystemChannelFlag  `json:"system_channel_flags,omitempty"`
-	RulesChannelID              string             `json:"rules_channel_id,omitempty"`
-	PublicUpdatesChannelID      string             `json:"public_updates_channel_id,omitempty"`
-	PreferredLocale             Locale             `json:"preferred_locale,omitempty"`
-	Features                    []GuildFeature     `json:"features,omitempty"`
-	Description                 string             `json:"description,omitempty"`
-	PremiumProgressBarEnabled   *bool              `json:"premium_progress_bar_enabled,omitempty"`
+	Name                        string                      `json:"name,omitempty"`
+	Region                      string                      `json:"region,omitempty"`
+	VerificationLevel           *VerificationLevel          `json:"verification_level,omitempty"`
+	DefaultMessageNotifications *MessageNotifications       `json:"default_message_notifications,omitempty"`
+	ExplicitContentFilter       *ExplicitContentFilterLevel `json:"explicit_content_filter,omitempty"`
+	AfkChannelID                string                      `json:"afk_channel_id,omitempty"`
+	AfkTimeout                  int                         `json:"afk_timeout,omitempty"`
+	Icon                        string                      `json:"icon,omitempty"`
+	OwnerID                     string                      `json:"owner_id,omitempty"`
+	Splash                      string                      `json:"splash,omitempty"`
+	DiscoverySplash             string                      `json:"discovery_splash,omitempty"`
+	Banner                      string                      `json:"banner,omitempty"`
+	SystemChannelID             string                      `json:"system_channel_id,omitempty"`
+	SystemChannelFlags          SystemChannelFlag           `json:"system_channel_flags,omitempty"`
+	RulesChannelID              string                      `json:"rules_channel_id,omitempty"`
+	PublicUpdatesChannelID      string                      `json:"public_updates_channel_id,omitempty"`
+	PreferredLocale             Locale                      `json:"preferred_locale,omitempty"`
+	Features                    []GuildFeature              `json:"features,omitempty"`
+	Description                 string                      `json:"description,omitempty"`
+	PremiumProgressBarEnabled   *bool                       `json:"premium_progress_bar_enabled,omitempty"`
 }
 
 // A Role stores information about Discord guild member roles.
@@ -1422,6 +1549,12 @@ func (r *Role) Mention() string {
 	return fmt.Sprintf("<@&%s>", r.ID)
 }
 
+// ColorHex returns the color of the role in the #rrggbb form.
+// Roles without a color return "#000000".
+func (r *Role) ColorHex() string {
+	return fmt.Sprintf("#%06x", r.Color&0xffffff)
+}
+
 // IconURL returns the URL of the role's icon.
 //
 //	size:    The size of the desired role icon as a power of two
@@ -1454,7 +1587,7 @@ type RoleParams struct {
 	// The role's unicode emoji.
 	// NOTE: can only be set if the guild has the ROLE_ICONS feature.
 	UnicodeEmoji *string `json:"unicode_emoji,omitempty"`
-	// The role's icon image encoded in base64.
+	// The role's icon image encoded in base64, see ImageDataURI.
 	// NOTE: can only be set if the guild has the ROLE_ICONS feature.
 	Icon *string `json:"icon,omitempty"`
 }
@@ -1616,7 +1749,7 @@ func (m *Member) DisplayName() string {
 	if m.Nick != "" {
 		return m.Nick
 	}
-	return m.User.GlobalName
+	return m.User.DisplayName()
 }
 
 // ClientStatus stores the online, offline, idle, or dnd status of each device of a Guild member.
@@ -1644,6 +1777,9 @@ type TooManyRequests struct {
 	Bucket     string        `json:"bucket"`
 	Message    string        `json:"message"`
 	RetryAfter time.Duration `json:"retry_after"`
+
+	// Whether the global ratelimit was hit, rather than a per-route one.
+	Global bool `json:"global"`
 }
 
 // UnmarshalJSON helps support translation of a milliseconds-based float
@@ -1653,6 +1789,7 @@ func (t *TooManyRequests) UnmarshalJSON(b []byte) error {
 		Bucket     string  `json:"bucket"`
 		Message    string  `json:"message"`
 		RetryAfter float64 `json:"retry_after"`
+		Global     bool    `json:"global"`
 	}{}
 	err := Unmarshal(b, &u)
 	if err != nil {
@@ -1661,6 +1798,7 @@ func (t *TooManyRequests) UnmarshalJSON(b []byte) error {
 
 	t.Bucket = u.Bucket
 	t.Message = u.Message
+	t.Global = u.Global
 	whole, frac := math.Modf(u.RetryAfter)
 	t.RetryAfter = time.Duration(whole)*time.Second + time.Duration(frac*1000)*time.Millisecond
 	return nil
@@ -8,6 +8,7 @@
 package discordgo
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -34,3 +35,79 @@ func TestMember_DisplayName(t *testing.T) {
 		}
 	})
 }
+
+func TestEmoji_URL(t *testing.T) {
+	tests := []struct {
+		emoji *Emoji
+		want  string
+	}{
+		{&Emoji{ID: "123", Name: "static"}, "https://cdn.discordapp.com/emojis/123.png"},
+		{&Emoji{ID: "456", Name: "animated", Animated: true}, "https://cdn.discordapp.com/emojis/456.gif"},
+		{&Emoji{Name: "👍"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.emoji.URL(); got != tt.want {
+			t.Errorf("Emoji(%s).URL() = %q, want %q", tt.emoji.Name, got, tt.want)
+		}
+	}
+}
+
+func TestSticker_URL(t *testing.T) {
+	tests := []struct {
+		format StickerFormat
+		want   string
+	}{
+		{StickerFormatTypePNG, "https://cdn.discordapp.com/stickers/1.png"},
+		{StickerFormatTypeAPNG, "https://cdn.discordapp.com/stickers/1.png"},
+		{StickerFormatTypeLottie, "https://cdn.discordapp.com/stickers/1.json"},
+		{StickerFormatTypeGIF, "https://media.discordapp.net/stickers/1.gif"},
+	}
+	for _, tt := range tests {
+		if got := (&Sticker{ID: "1", FormatType: tt.format}).URL(); got != tt.want {
+			t.Errorf("Sticker(format %d).URL() = %q, want %q", tt.format, got, tt.want)
+		}
+		if got := (&StickerItem{ID: "1", FormatType: tt.format}).URL(); got != tt.want {
+			t.Errorf("StickerItem(format %d).URL() = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestGuild_HasFeature(t *testing.T) {
+	g := &Guild{Features: []GuildFeature{GuildFeatureCommunity, GuildFeatureBanner}}
+
+	if !g.HasFeature(GuildFeatureCommunity) {
+		t.Error("HasFeature(COMMUNITY) = false, want true")
+	}
+	if !g.HasFeature("BANNER") {
+		t.Error("HasFeature(BANNER) = false, want true")
+	}
+	if g.HasFeature(GuildFeatureVanityURL) {
+		t.Error("HasFeature(VANITY_URL) = true, want false")
+	}
+}
+
+func TestRole_ColorHex(t *testing.T) {
+	tests := map[int]string{
+		0:        "#000000",
+		0x1abc9c: "#1abc9c",
+		0xff:     "#0000ff",
+	}
+	for color, want := range tests {
+		r := &Role{Color: color}
+		if got := r.ColorHex(); got != want {
+			t.Errorf("Role{Color: %d}.ColorHex() = %q, want %q", color, got, want)
+		}
+	}
+}
+
+func TestGuildParams_ZeroLevels(t *testing.T) {
+	notifications := MessageNotificationsAllMessages
+	filter := ExplicitContentFilterDisabled
+	b, err := json.Marshal(GuildParams{DefaultMessageNotifications: &notifications, ExplicitContentFilter: &filter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"default_message_notifications":0,"explicit_content_filter":0}`; string(b) != want {
+		t.Errorf("json.Marshal(GuildParams) = %s, want %s", b, want)
+	}
+}
@@ -102,6 +102,17 @@ type User struct {
 	Flags int `json:"flags"`
 }
 
+// UserUpdateParams stores the settings of the session user to change with
+// UserUpdateComplex. Fields left nil are not changed.
+type UserUpdateParams struct {
+	// The new username.
+	Username *string `json:"username,omitempty"`
+	// The new avatar, as an image data URI (see ImageDataURI).
+	Avatar *string `json:"avatar,omitempty"`
+	// The new banner, as an image data URI (see ImageDataURI).
+	Banner *string `json:"banner,omitempty"`
+}
+
 // String returns a unique identifier of the form username#discriminator
 // or just username, if the discriminator is set to "0".
 func (u *User) String() string {
@@ -114,6 +125,15 @@ func (u *User) String() string {
 	return u.Username + "#" + u.Discriminator
 }
 
+// DisplayName returns the user's global display name if they have one,
+// otherwise it returns their username.
+func (u *User) DisplayName() string {
+	if u.GlobalName != "" {
+		return u.GlobalName
+	}
+	return u.Username
+}
+
 // Mention return a string which mentions the user
 func (u *User) Mention() string {
 	return "<@" + u.ID + ">"
@@ -35,3 +35,38 @@ func TestUser_String(t *testing.T) {
 		})
 	}
 }
+
+func TestUser_DisplayName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		u    *User
+		want string
+	}{
+		{
+			name: "User with a global name",
+			u: &User{
+				Username:      "bob",
+				GlobalName:    "Bob",
+				Discriminator: "0",
+			},
+			want: "Bob",
+		},
+		{
+			name: "User without a global name",
+			u: &User{
+				Username:      "bob",
+				Discriminator: "8192",
+			},
+			want: "bob",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.u.DisplayName(); got != tc.want {
+				t.Errorf("User.DisplayName() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
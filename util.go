@@ -2,9 +2,11 @@ package discordgo
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"net/textproto"
 	"strconv"
 	"strings"
@@ -22,6 +24,38 @@ func SnowflakeTimestamp(ID string) (t time.Time, err error) {
 	return
 }
 
+// Styles for FormatTimestamp, see
+// https://discord.com/developers/docs/reference#message-formatting-timestamp-styles
+const (
+	TimestampStyleShortTime     = 't' // 16:20
+	TimestampStyleLongTime      = 'T' // 16:20:30
+	TimestampStyleShortDate     = 'd' // 20/04/2021
+	TimestampStyleLongDate      = 'D' // 20 April 2021
+	TimestampStyleShortDateTime = 'f' // 20 April 2021 16:20
+	TimestampStyleLongDateTime  = 'F' // Tuesday, 20 April 2021 16:20
+	TimestampStyleRelative      = 'R' // 2 months ago
+)
+
+// FormatTimestamp returns a timestamp token which Discord renders in each
+// user's own timezone, such as <t:1618953630:R>.
+// t     : The time to display.
+// style : One of the TimestampStyle constants, or 0 for the default style.
+func FormatTimestamp(t time.Time, style rune) string {
+	unix := strconv.FormatInt(t.Unix(), 10)
+	if style == 0 {
+		return "<t:" + unix + ">"
+	}
+	return "<t:" + unix + ":" + string(style) + ">"
+}
+
+// ImageDataURI encodes image data as a base64 data URI, the format expected
+// by fields such as RoleParams.Icon, GuildParams.Icon or a user avatar.
+// The content type is detected from the data itself.
+// data : The raw image data (PNG, JPEG or GIF).
+func ImageDataURI(data []byte) string {
+	return "data:" + http.DetectContentType(data) + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
 // MultipartBodyWithJSON returns the contentType and body for a discord request
 // data  : The object to encode for payload_json in the multipart request
 // files : Files to include in the request
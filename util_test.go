@@ -19,3 +19,28 @@ func TestSnowflakeTimestamp(t *testing.T) {
 		t.Errorf("parsed time incorrect: got %v, want %v", parsedTimestamp, correctTimestamp)
 	}
 }
+
+func TestImageDataURI(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	want := "data:image/png;base64,iVBORw0KGgoAAAANSUhEUg=="
+	if got := ImageDataURI(png); got != want {
+		t.Errorf("ImageDataURI() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	ts := time.Date(2021, time.April, 20, 21, 20, 30, 0, time.UTC)
+	tests := []struct {
+		style rune
+		want  string
+	}{
+		{0, "<t:1618953630>"},
+		{TimestampStyleRelative, "<t:1618953630:R>"},
+		{TimestampStyleLongDateTime, "<t:1618953630:F>"},
+	}
+	for _, tt := range tests {
+		if got := FormatTimestamp(ts, tt.style); got != tt.want {
+			t.Errorf("FormatTimestamp(%q) = %q, want %q", tt.style, got, tt.want)
+		}
+	}
+}
@@ -120,6 +120,8 @@ func (v *VoiceConnection) ChangeChannel(channelID string, mute, deaf bool) (err
 	v.log(LogInformational, "called")
 
 	data := voiceChannelJoinOp{4, voiceChannelJoinData{&v.GuildID, &channelID, mute, deaf}}
+	v.session.gatewayLimiter.wait()
+	v.session.gatewayWriteHook(4, data)
 	v.session.wsMutex.Lock()
 	err = v.session.wsConn.WriteJSON(data)
 	v.session.wsMutex.Unlock()
@@ -142,6 +144,8 @@ func (v *VoiceConnection) Disconnect() (err error) {
 	v.Lock()
 	if v.sessionID != "" {
 		data := voiceChannelJoinOp{4, voiceChannelJoinData{&v.GuildID, nil, true, true}}
+		v.session.gatewayLimiter.wait()
+		v.session.gatewayWriteHook(4, data)
 		v.session.wsMutex.Lock()
 		err = v.session.wsConn.WriteJSON(data)
 		v.session.wsMutex.Unlock()
@@ -248,27 +252,36 @@ type voiceOP2 struct {
 	IP                string        `json:"ip"`
 }
 
-// WaitUntilConnected waits for the Voice Connection to
-// become ready, if it does not become ready it returns an err
-func (v *VoiceConnection) waitUntilConnected() error {
+// waitUntilConnected waits for the Voice Connection to become ready
+// within timeout, otherwise it returns an error wrapping ErrVoiceJoinTimeout
+// which describes how far the connection got.
+func (v *VoiceConnection) waitUntilConnected(timeout time.Duration) error {
 
 	v.log(LogInformational, "called")
 
-	i := 0
+	deadline := time.Now().Add(timeout)
 	for {
 		v.RLock()
 		ready := v.Ready
+		stateReceived := v.sessionID != ""
+		serverReceived := v.token != ""
 		v.RUnlock()
 		if ready {
 			return nil
 		}
 
-		if i > 10 {
-			return fmt.Errorf("timeout waiting for voice")
+		if time.Now().After(deadline) {
+			switch {
+			case !stateReceived:
+				return fmt.Errorf("%w waiting for voice state update", ErrVoiceJoinTimeout)
+			case !serverReceived:
+				return fmt.Errorf("%w waiting for voice server update (voice state update received)", ErrVoiceJoinTimeout)
+			default:
+				return fmt.Errorf("%w waiting for voice websocket to be ready (voice state and server updates received)", ErrVoiceJoinTimeout)
+			}
 		}
 
-		time.Sleep(1 * time.Second)
-		i++
+		time.Sleep(100 * time.Millisecond)
 	}
 }
 
@@ -939,6 +952,8 @@ func (v *VoiceConnection) reconnect() {
 		// packet to reset things.
 		// Send a OP4 with a nil channel to disconnect
 		data := voiceChannelJoinOp{4, voiceChannelJoinData{&v.GuildID, nil, true, true}}
+		v.session.gatewayLimiter.wait()
+		v.session.gatewayWriteHook(4, data)
 		v.session.wsMutex.Lock()
 		err = v.session.wsConn.WriteJSON(data)
 		v.session.wsMutex.Unlock()
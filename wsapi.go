@@ -13,6 +13,7 @@ package discordgo
 import (
 	"bytes"
 	"compress/zlib"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -36,6 +37,49 @@ var ErrWSNotFound = errors.New("no websocket connection exists")
 // more than the total shard count
 var ErrWSShardBounds = errors.New("ShardID must be less than ShardCount")
 
+// ErrVoiceJoinTimeout is returned by ChannelVoiceJoin when the voice
+// connection is not ready within Session.VoiceJoinTimeout, e.g. because
+// the bot lacks the Connect permission on the channel.
+var ErrVoiceJoinTimeout = errors.New("voice connection timed out")
+
+// ConnectionStatus is the status of the gateway websocket connection.
+type ConnectionStatus int32
+
+// Block of valid ConnectionStatus values
+const (
+	ConnectionStatusDisconnected ConnectionStatus = iota
+	ConnectionStatusConnecting
+	ConnectionStatusIdentifying
+	ConnectionStatusReady
+	ConnectionStatusReconnecting
+)
+
+// String returns the name of the connection status.
+func (c ConnectionStatus) String() string {
+	switch c {
+	case ConnectionStatusDisconnected:
+		return "disconnected"
+	case ConnectionStatusConnecting:
+		return "connecting"
+	case ConnectionStatusIdentifying:
+		return "identifying"
+	case ConnectionStatusReady:
+		return "ready"
+	case ConnectionStatusReconnecting:
+		return "reconnecting"
+	}
+	return "unknown"
+}
+
+// ConnectionStatus returns the current status of the gateway connection.
+func (s *Session) ConnectionStatus() ConnectionStatus {
+	return ConnectionStatus(atomic.LoadInt32(&s.status))
+}
+
+func (s *Session) setConnectionStatus(status ConnectionStatus) {
+	atomic.StoreInt32(&s.status, int32(status))
+}
+
 type resumePacket struct {
 	Op   int `json:"op"`
 	Data struct {
@@ -62,6 +106,17 @@ func (s *Session) Open() error {
 		return ErrWSAlreadyOpen
 	}
 
+	// While reconnecting, the status is kept until identifying.
+	reconnecting := s.ConnectionStatus() == ConnectionStatusReconnecting
+	if !reconnecting {
+		s.setConnectionStatus(ConnectionStatusConnecting)
+	}
+	defer func() {
+		if err != nil {
+			s.setConnectionStatus(ConnectionStatusDisconnected)
+		}
+	}()
+
 	// Get the gateway to use for the Websocket connection
 	if s.gateway == "" {
 		s.gateway, err = s.Gateway()
@@ -89,6 +144,9 @@ func (s *Session) Open() error {
 		return nil
 	})
 
+	// Commands sent on a previous connection do not count towards this one.
+	s.gatewayLimiter.clear()
+
 	defer func() {
 		// because of this, all code below must set err to the error
 		// when exiting with an error :)  Maybe someone has a better
@@ -123,8 +181,11 @@ func (s *Session) Open() error {
 
 	// Now we send either an Op 2 Identity if this is a brand new
 	// connection or Op 6 Resume if we are resuming an existing connection.
+	s.setConnectionStatus(ConnectionStatusIdentifying)
+
 	sequence := atomic.LoadInt64(s.sequence)
-	if s.sessionID == "" && sequence == 0 {
+	resuming := s.sessionID != "" || sequence != 0
+	if !resuming {
 
 		// Send Op 2 Identity Packet
 		err = s.identify()
@@ -138,11 +199,12 @@ func (s *Session) Open() error {
 		// Send Op 6 Resume Packet
 		p := resumePacket{}
 		p.Op = 6
-		p.Data.Token = s.Token
+		p.Data.Token = s.authorization(s.Token)
 		p.Data.SessionID = s.sessionID
 		p.Data.Sequence = sequence
 
 		s.log(LogInformational, "sending resume packet to gateway")
+		s.gatewayWriteHook(p.Op, p)
 		s.wsMutex.Lock()
 		err = s.wsConn.WriteJSON(p)
 		s.wsMutex.Unlock()
@@ -185,6 +247,13 @@ func (s *Session) Open() error {
 	s.log(LogInformational, "We are now connected to Discord, emitting connect event")
 	s.handleEvent(connectEventType, &Connect{})
 
+	if reconnecting {
+		// Discord replays the missed events before RESUMED, so the first
+		// packet only tells whether the resume was rejected.
+		resumed := resuming && e.Operation != 9 && e.Type != readyEventType
+		s.handleEvent(reconnectEventType, &Reconnect{Resumed: resumed})
+	}
+
 	// A VoiceConnections map is a hard requirement for Voice.
 	// XXX: can this be moved to when opening a voice connection?
 	if s.VoiceConnections == nil {
@@ -293,6 +362,7 @@ func (s *Session) heartbeat(wsConn *websocket.Conn, listening <-chan interface{}
 		s.RUnlock()
 		sequence := atomic.LoadInt64(s.sequence)
 		s.log(LogDebug, "sending gateway websocket heartbeat seq %d", sequence)
+		s.gatewayWriteHook(1, heartbeatOp{1, sequence})
 		s.wsMutex.Lock()
 		s.LastHeartbeatSent = time.Now().UTC()
 		err = wsConn.WriteJSON(heartbeatOp{1, sequence})
@@ -423,12 +493,15 @@ func (s *Session) UpdateStatusComplex(usd UpdateStatusData) (err error) {
 		usd.Activities = make([]*Activity, 0)
 	}
 
+	s.gatewayLimiter.wait()
+
 	s.RLock()
 	defer s.RUnlock()
 	if s.wsConn == nil {
 		return ErrWSNotFound
 	}
 
+	s.gatewayWriteHook(3, updateStatusOp{3, usd})
 	s.wsMutex.Lock()
 	err = s.wsConn.WriteJSON(updateStatusOp{3, usd})
 	s.wsMutex.Unlock()
@@ -517,12 +590,17 @@ func (s *Session) RequestGuildMembersBatchList(guildIDs []string, userIDs []stri
 
 // GatewayWriteStruct allows for sending raw gateway structs over the gateway.
 func (s *Session) GatewayWriteStruct(data interface{}) (err error) {
+	s.gatewayLimiter.wait()
+
 	s.RLock()
 	defer s.RUnlock()
 	if s.wsConn == nil {
 		return ErrWSNotFound
 	}
 
+	if s.GatewayWriteHook != nil {
+		s.gatewayWriteHook(gatewayPayloadOp(data), data)
+	}
 	s.wsMutex.Lock()
 	err = s.wsConn.WriteJSON(data)
 	s.wsMutex.Unlock()
@@ -533,12 +611,15 @@ func (s *Session) GatewayWriteStruct(data interface{}) (err error) {
 func (s *Session) requestGuildMembers(data requestGuildMembersData) (err error) {
 	s.log(LogInformational, "called")
 
+	s.gatewayLimiter.wait()
+
 	s.RLock()
 	defer s.RUnlock()
 	if s.wsConn == nil {
 		return ErrWSNotFound
 	}
 
+	s.gatewayWriteHook(8, requestGuildMembersOp{8, data})
 	s.wsMutex.Lock()
 	err = s.wsConn.WriteJSON(requestGuildMembersOp{8, data})
 	s.wsMutex.Unlock()
@@ -593,8 +674,10 @@ func (s *Session) onEvent(messageType int, message []byte) (*Event, error) {
 	// Must respond with a heartbeat packet within 5 seconds
 	if e.Operation == 1 {
 		s.log(LogInformational, "sending heartbeat in response to Op1")
+		heartbeat := heartbeatOp{1, atomic.LoadInt64(s.sequence)}
+		s.gatewayWriteHook(1, heartbeat)
 		s.wsMutex.Lock()
-		err = s.wsConn.WriteJSON(heartbeatOp{1, atomic.LoadInt64(s.sequence)})
+		err = s.wsConn.WriteJSON(heartbeat)
 		s.wsMutex.Unlock()
 		if err != nil {
 			s.log(LogError, "error sending heartbeat in response to Op1")
@@ -619,6 +702,10 @@ func (s *Session) onEvent(messageType int, message []byte) (*Event, error) {
 
 		s.log(LogInformational, "sending identify packet to gateway in response to Op9")
 
+		// A new session starts its sequence over.
+		atomic.StoreInt64(s.sequence, 0)
+		s.setConnectionStatus(ConnectionStatusIdentifying)
+
 		err = s.identify()
 		if err != nil {
 			s.log(LogWarning, "error sending gateway identify packet, %s, %s", s.gateway, err)
@@ -649,9 +736,20 @@ func (s *Session) onEvent(messageType int, message []byte) (*Event, error) {
 		return e, nil
 	}
 
+	// Drop events that were already dispatched, which Discord may replay
+	// after a resume. READY always starts a new session.
+	if e.Type != readyEventType && e.Sequence <= atomic.LoadInt64(s.sequence) {
+		s.log(LogInformational, "dropping already dispatched event: Seq: %d, Type: %s", e.Sequence, e.Type)
+		return e, nil
+	}
+
 	// Store the message sequence
 	atomic.StoreInt64(s.sequence, e.Sequence)
 
+	if e.Type == readyEventType || e.Type == resumedEventType {
+		s.setConnectionStatus(ConnectionStatusReady)
+	}
+
 	// Map event to registered event handlers and pass it along to any registered handlers.
 	if eh, ok := registeredInterfaceProviders[e.Type]; ok {
 		e.Struct = eh.New()
@@ -668,6 +766,12 @@ func (s *Session) onEvent(messageType int, message []byte) (*Event, error) {
 		// it's better to pass along what we received than nothing at all.
 		// TODO: Think about that decision :)
 		// Either way, READY events must fire, even with errors.
+		s.handleEvent(e.Type, e.Struct)
+	} else if e.Struct = s.newCustomEvent(e.Type); e.Struct != nil {
+		if err = json.Unmarshal(e.RawData, e.Struct); err != nil {
+			s.log(LogError, "error unmarshalling %s event, %s", e.Type, err)
+		}
+
 		s.handleEvent(e.Type, e.Struct)
 	} else {
 		s.log(LogWarning, "unknown event: Op: %d, Seq: %d, Type: %s, Data: %s", e.Operation, e.Sequence, e.Type, string(e.RawData))
@@ -679,6 +783,26 @@ func (s *Session) onEvent(messageType int, message []byte) (*Event, error) {
 	return e, nil
 }
 
+// gatewayWriteHook calls the GatewayWriteHook of the session, if set.
+func (s *Session) gatewayWriteHook(op int, data interface{}) {
+	if s.GatewayWriteHook != nil {
+		s.GatewayWriteHook(op, data)
+	}
+}
+
+// gatewayPayloadOp returns the opcode of a raw gateway payload, or -1 if it
+// does not have one.
+func gatewayPayloadOp(data interface{}) int {
+	var p struct {
+		Op *int `json:"op"`
+	}
+	b, err := Marshal(data)
+	if err != nil || json.Unmarshal(b, &p) != nil || p.Op == nil {
+		return -1
+	}
+	return *p.Op
+}
+
 // ------------------------------------------------------------------------------------------------
 // Code related to voice connections that initiate over the data websocket
 // ------------------------------------------------------------------------------------------------
@@ -729,8 +853,12 @@ func (s *Session) ChannelVoiceJoin(gID, cID string, mute, deaf bool) (voice *Voi
 		return
 	}
 
-	// doesn't exactly work perfect yet.. TODO
-	err = voice.waitUntilConnected()
+	timeout := s.VoiceJoinTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	err = voice.waitUntilConnected(timeout)
 	if err != nil {
 		s.log(LogWarning, "error waiting for voice to connect, %s", err)
 		voice.Close()
@@ -761,6 +889,8 @@ func (s *Session) ChannelVoiceJoinManual(gID, cID string, mute, deaf bool) (err
 
 	// Send the request to Discord that we want to join the voice channel
 	data := voiceChannelJoinOp{4, voiceChannelJoinData{&gID, channelID, mute, deaf}}
+	s.gatewayLimiter.wait()
+	s.gatewayWriteHook(4, data)
 	s.wsMutex.Lock()
 	err = s.wsConn.WriteJSON(data)
 	s.wsMutex.Unlock()
@@ -867,7 +997,9 @@ func (s *Session) identify() error {
 
 	// Send Identify packet to Discord
 	op := identifyOp{2, s.Identify}
+	op.Data.Token = s.authorization(op.Data.Token)
 	s.log(LogDebug, "Identify Packet: \n%#v", op)
+	s.gatewayWriteHook(2, op)
 	s.wsMutex.Lock()
 	err := s.wsConn.WriteJSON(op)
 	s.wsMutex.Unlock()
@@ -886,6 +1018,8 @@ func (s *Session) reconnect() {
 		wait := time.Duration(1)
 
 		for {
+			s.setConnectionStatus(ConnectionStatusReconnecting)
+
 			s.log(LogInformational, "trying to reconnect to gateway")
 
 			err = s.Open()
@@ -922,6 +1056,9 @@ func (s *Session) reconnect() {
 
 			s.log(LogError, "error reconnecting to gateway, %s", err)
 
+			// Open reports the failed attempt as disconnected, while the
+			// session is still reconnecting.
+			s.setConnectionStatus(ConnectionStatusReconnecting)
 			<-time.After(wait * time.Second)
 			wait *= 2
 			if wait > 600 {
@@ -980,6 +1117,7 @@ func (s *Session) CloseWithCode(closeCode int) (err error) {
 		s.wsConn = nil
 	}
 
+	s.setConnectionStatus(ConnectionStatusDisconnected)
 	s.Unlock()
 
 	s.log(LogInformational, "emit disconnect event")
@@ -987,3 +1125,74 @@ func (s *Session) CloseWithCode(closeCode int) (err error) {
 
 	return
 }
+
+// CloseGracefully disconnects all voice connections, closes the websocket
+// with a normal closure and stops the listening/heartbeat goroutines, then
+// waits for event handlers that are still running to return. Events emitted
+// while waiting, such as RateLimit events, are not passed to new handlers.
+// If ctx is done before the voice connections are disconnected or the
+// handlers return, ctx.Err() is returned.
+func (s *Session) CloseGracefully(ctx context.Context) error {
+	s.log(LogInformational, "called")
+
+	s.RLock()
+	connected := s.wsConn != nil
+	voices := make([]*VoiceConnection, 0, len(s.VoiceConnections))
+	for _, v := range s.VoiceConnections {
+		voices = append(voices, v)
+	}
+	s.RUnlock()
+
+	// Disconnecting may wait on the gateway rate limit, so it is done in its
+	// own goroutine to honour ctx.
+	voicesDone := make(chan struct{})
+	go func() {
+		defer close(voicesDone)
+		for _, v := range voices {
+			if connected {
+				s.log(LogInformational, "disconnecting voice connection to guild %s", v.GuildID)
+				if err := v.Disconnect(); err != nil {
+					s.log(LogWarning, "error disconnecting voice connection to guild %s, %s", v.GuildID, err)
+				}
+			} else {
+				v.Close()
+			}
+		}
+	}()
+
+	select {
+	case <-voicesDone:
+	case <-ctx.Done():
+		s.log(LogWarning, "voice connections were not disconnected, %s", ctx.Err())
+	}
+
+	err := s.CloseWithCode(websocket.CloseNormalClosure)
+	if err != nil {
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	// Stop new handlers, so the WaitGroup is not added to while waiting.
+	s.handlersMu.Lock()
+	s.handlersClosing++
+	s.handlersMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.handlersWg.Wait()
+
+		s.handlersMu.Lock()
+		s.handlersClosing--
+		s.handlersMu.Unlock()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}